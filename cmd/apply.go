@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/m8/internal/history"
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func apply() *cobra.Command {
+	apply := &cobra.Command{
+		Use:   "apply",
+		Short: "deploy and test channel contracts from manifests of any type, in file order",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifestPath := viper.GetString(manifestFile)
+			if _, err := manifest.Paths(manifestPath); errors.Is(err, os.ErrNotExist) {
+				return errors.New("unable to locate manifest")
+			}
+
+			manifests, err := manifest.Resolve(manifestPath, manifest.WithEnvironment(viper.GetString(environment)))
+			if err != nil {
+				return err
+			}
+
+			filter, err := manifest.NewFilter(viper.GetString(run), viper.GetStringSlice(tags), viper.GetStringSlice(skipTags))
+			if err != nil {
+				return err
+			}
+
+			config, _ := viper.Get("cfg").(*cfg.Configuration)
+			confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+			if err := confirm.ConfirmDestructive(manifest.DestructiveChannels(manifests)); err != nil {
+				return err
+			}
+
+			pk, err := crypto.FromHex(viper.GetString(privateKey))
+			if err != nil {
+				return err
+			}
+
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
+				return err
+			}
+
+			runner := manifest.NewRunner(client, viper.GetString(publicKey), pk)
+			runner.HistoryDir = history.Dir(viper.GetString(cfgPath))
+			runner.Guard = confirm.Guard
+			runner.Parallel = viper.GetInt(parallel)
+			runner.Filter = filter
+			runner.UpdateSnapshots = viper.GetBool(updateSnapshots)
+			runner.Report = manifest.NewReport(viper.GetBool(metrics), viper.GetString(reportFile))
+			results, err := runner.Apply(cmd.Context(), manifests)
+			if rerr := printApplied(results); err == nil {
+				err = rerr
+			}
+			if rerr := runner.Report.Write(); err == nil {
+				err = rerr
+			}
+			return err
+		},
+	}
+	apply.Flags().StringP(manifestFile, "f", "", "manifest file, directory, glob or - for stdin to apply")
+	apply.MarkFlagRequired(manifestFile)
+	apply.Flags().Bool(force, false, "allow manifests to reset, pause or delete the contract of a protected channel")
+	apply.Flags().Int(parallel, 4, "number of independent tests run at once")
+	apply.Flags().String(run, "", "only run tests with names matching the regular expression")
+	apply.Flags().StringSlice(tags, []string{}, "only run tests with at least one of the tags")
+	apply.Flags().StringSlice(skipTags, []string{}, "skip tests with any of the tags")
+	apply.Flags().Bool(metrics, false, "print a summary of the transaction metrics of each deployment and test")
+	apply.Flags().Bool(updateSnapshots, false, "rewrite the snapshots of snapshot transactions")
+	apply.Flags().String(reportFile, "", "write the results and transaction metrics as json to the file")
+	return apply
+}
+
+// printApplied prints the status of each manifest along with why it failed, was skipped or was not run
+func printApplied(results []*manifest.ApplyResult) error {
+	data := pterm.TableData{{"manifest", "type", "status", "reason"}}
+	for _, r := range results {
+		data = append(data, []string{r.Path, r.Type, r.Status, r.Reason})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
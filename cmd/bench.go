@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/bench"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func benchmark() *cobra.Command {
+	bench := &cobra.Command{
+		Use:   "bench",
+		Short: "generate load against a channel contract and report latency and throughput",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if viper.GetInt(number) < 1 {
+				return fmt.Errorf("--%s must be at least 1", number)
+			}
+			if viper.GetInt(concurrency) < 1 {
+				return fmt.Errorf("--%s must be at least 1", concurrency)
+			}
+
+			calls, err := benchCalls()
+			if err != nil {
+				return err
+			}
+
+			pk, err := crypto.FromHex(viper.GetString(privateKey))
+			if err != nil {
+				return err
+			}
+
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
+				return err
+			}
+
+			spinner, err := pterm.DefaultSpinner.Start(fmt.Sprintf("submitting %d transactions...", viper.GetInt(number)))
+			if err != nil {
+				return err
+			}
+			report, err := bench.Run(cmd.Context(), client, &bench.Config{
+				ChannelID:   viper.GetString(channelId),
+				Sender:      viper.GetString(publicKey),
+				PrivKey:     pk,
+				Calls:       calls,
+				Total:       viper.GetInt(number),
+				Concurrency: viper.GetInt(concurrency),
+				Rate:        viper.GetFloat64(rate),
+			})
+			if err != nil {
+				spinner.Fail(err.Error())
+				return err
+			}
+			spinner.Success(fmt.Sprintf("%d transactions complete in %s", report.Total, report.Duration.Round(time.Millisecond)))
+
+			if viper.GetBool(jsonOutput) {
+				v, err := json.MarshalIndent(report, "", "\t")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(v))
+				return nil
+			}
+			return renderBenchReport(report)
+		},
+	}
+	bench.Flags().String(function, "", "the function to be called")
+	bench.Flags().StringArray(arguments, []string{}, "the args to pass within the function")
+	bench.Flags().String(benchManifest, "", "deployment or test manifest whose transactions are submitted round robin instead of --fn")
+	bench.Flags().Int(number, 100, "number of transactions to submit")
+	bench.Flags().Int(concurrency, 10, "number of transactions in flight at once")
+	bench.Flags().Float64(rate, 0, "maximum transactions submitted per second, 0 is unlimited")
+	bench.Flags().Bool(jsonOutput, false, "print the report as json")
+	return bench
+}
+
+// benchCalls returns the calls from the manifest template or the function flags
+func benchCalls() ([]*bench.Call, error) {
+	calls := make([]*bench.Call, 0)
+	if path := viper.GetString(benchManifest); path != "" {
+		manifests, err := manifest.Resolve(path, manifest.WithEnvironment(viper.GetString(environment)))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range manifests {
+			txs := make([]*manifest.Tx, 0)
+			if m.Deploy != nil {
+				txs = append(txs, m.Deploy.Transactions...)
+			}
+			for _, t := range m.Tests {
+				txs = append(txs, t.Transactions...)
+			}
+			for _, t := range txs {
+				// only call transactions are benchmarked, other steps are skipped
+				if t.Tx != nil {
+					calls = append(calls, &bench.Call{Function: t.Tx.Function, Args: t.Tx.Args})
+				}
+			}
+		}
+		if len(calls) == 0 {
+			return nil, errors.New("no transactions found in manifest " + path)
+		}
+		return calls, nil
+	}
+
+	if viper.GetString(function) == "" {
+		return nil, fmt.Errorf("one of --%s or --%s is required", function, benchManifest)
+	}
+	return append(calls, &bench.Call{
+		Function: viper.GetString(function),
+		Args:     viper.GetStringSlice(arguments),
+	}), nil
+}
+
+func renderBenchReport(report *bench.Report) error {
+	summary := pterm.TableData{
+		{"total", fmt.Sprint(report.Total)},
+		{"succeeded", fmt.Sprint(report.Succeeded)},
+		{"submit errors", fmt.Sprint(report.SubmitErrors)},
+		{"receipt errors", fmt.Sprint(report.ReceiptErrors)},
+		{"throughput", fmt.Sprintf("%.2f tx/s", report.Throughput)},
+		{"block inclusion", fmt.Sprintf("mean %.2f blocks, max %d blocks", report.InclusionBlocksMean, report.InclusionBlocksMax)},
+	}
+	statuses := make([]string, 0, len(report.Statuses))
+	for s := range report.Statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	for _, s := range statuses {
+		summary = append(summary, []string{"status " + s, fmt.Sprint(report.Statuses[s])})
+	}
+	if err := pterm.DefaultTable.WithData(summary).Render(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	round := func(d time.Duration) string {
+		return d.Round(time.Microsecond).String()
+	}
+	latencies := pterm.TableData{{"latency", "min", "mean", "p50", "p90", "p99", "max"}}
+	for _, l := range []struct {
+		name    string
+		latency bench.Latency
+	}{{"submit", report.Submit}, {"receipt", report.Receipt}} {
+		latencies = append(latencies, []string{l.name,
+			round(l.latency.Min), round(l.latency.Mean), round(l.latency.P50),
+			round(l.latency.P90), round(l.latency.P99), round(l.latency.Max)})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(latencies).Render(); err != nil {
+		return err
+	}
+
+	for _, e := range report.Errors {
+		pterm.Error.Println(e)
+	}
+	return nil
+}
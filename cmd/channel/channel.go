@@ -13,7 +13,11 @@ func ChannelCmdChain() *cobra.Command {
 	channelRootCmd.AddCommand(
 		lookup(),
 		list(),
-		exec())
+		exec(),
+		pause(),
+		resume(),
+		status(),
+		nodes())
 
 	return channelRootCmd
 }
@@ -3,26 +3,47 @@ package channel
 import (
 	"errors"
 	"os"
+	"strconv"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/m8/internal/history"
 	"github.com/kochavalabs/m8/internal/manifest"
 	"github.com/kochavalabs/m8/internal/tui"
 	"github.com/kochavalabs/mazzaroth-go"
 	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 const (
+	maxBlockExpirationRange = 10
+
 	function   = `fn`
 	arguments  = `args`
 	privateKey = `private-key`
 	publicKey  = `public-key`
 
-	maxBlockExpirationRange       = 10
 	deploymentManifest            = `deployment-manifest`
 	testManifest                  = `test-manifest`
+	cfgPath                       = `cfg-path`
+	yes                           = `yes`
+	force                         = `force`
+	parallel                      = `parallel`
+	run                           = `run`
+	tags                          = `tags`
+	skipTags                      = `skip-tags`
+	listTests                     = `list`
+	watch                         = `watch`
+	metrics                       = `metrics`
+	reportFile                    = `report`
+	updateSnapshots               = `update-snapshots`
+	environment                   = `env`
 	defaultDeploymentManifestPath = `./m8/deployment.yaml`
 	defaultTestManifestPath       = `./m8/test.yaml`
 )
@@ -41,7 +62,7 @@ func execTx() *cobra.Command {
 		Use:   "tx",
 		Short: "execute functions on a mazzaroth channel",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
@@ -101,8 +122,8 @@ func execDeployment() *cobra.Command {
 		Short: "deploy a channel contract to mazzaroth nodes from a given manifest",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			manifestPath := viper.GetString(deploymentManifest)
-			// check if file is in default path
-			if _, err := os.Stat(manifestPath); errors.Is(err, os.ErrNotExist) {
+			// check if the manifest files exist, the default path included
+			if _, err := manifest.Paths(manifestPath); errors.Is(err, os.ErrNotExist) {
 				return errors.New("unable to locate deployment manifest")
 			}
 
@@ -111,24 +132,37 @@ func execDeployment() *cobra.Command {
 				return err
 			}
 
-			manifests, err := manifest.FromFile(manifestPath, "deployment")
+			manifests, err := manifest.FromFile(manifestPath, "deployment", manifest.WithEnvironment(viper.GetString(environment)))
 			if err != nil {
 				return err
 			}
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
-			if err != nil {
+			config, _ := viper.Get("cfg").(*cfg.Configuration)
+			confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+			if err := confirm.ConfirmDestructive(manifest.DestructiveChannels(manifests)); err != nil {
 				return err
 			}
 
-			if err := manifest.ExecuteDeployments(cmd.Context(), manifests, client, viper.GetString(publicKey), pk); err != nil {
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
 				return err
 			}
 
-			return nil
+			runner := manifest.NewRunner(client, viper.GetString(publicKey), pk)
+			runner.HistoryDir = history.Dir(viper.GetString(cfgPath))
+			runner.Guard = confirm.Guard
+			runner.Report = manifest.NewReport(viper.GetBool(metrics), viper.GetString(reportFile))
+			err = runner.ExecuteDeployments(cmd.Context(), manifests)
+			if rerr := runner.Report.Write(); err == nil {
+				err = rerr
+			}
+			return err
 		},
 	}
-	execDeployment.Flags().String(deploymentManifest, defaultDeploymentManifestPath, "location of mazzaroth channel deployment manifest")
+	execDeployment.Flags().String(deploymentManifest, defaultDeploymentManifestPath, "location of mazzaroth channel deployment manifests, a file, directory, glob or - for stdin")
+	execDeployment.Flags().Bool(force, false, "allow deployment steps to pause or delete the contract of a protected channel")
+	execDeployment.Flags().Bool(metrics, false, "print a summary of the transaction metrics of each deployment")
+	execDeployment.Flags().String(reportFile, "", "write the results and transaction metrics as json to the file")
 	return execDeployment
 }
 
@@ -138,33 +172,79 @@ func execTest() *cobra.Command {
 		Short: "test channel contracts on mazzaroth nodes",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			manifestPath := viper.GetString(testManifest)
-			// check if file is in default path
-			if _, err := os.Stat(manifestPath); errors.Is(err, os.ErrNotExist) {
+			// check if the manifest files exist, the default path included
+			if _, err := manifest.Paths(manifestPath); errors.Is(err, os.ErrNotExist) {
 				return errors.New("unable to locate test manifest")
 			}
 
-			manifests, err := manifest.FromFile(manifestPath, "test")
+			manifests, err := manifest.FromFile(manifestPath, "test", manifest.WithEnvironment(viper.GetString(environment)))
 			if err != nil {
 				return err
 			}
 
-			pk, err := crypto.FromHex(viper.GetString(privateKey))
+			filter, err := manifest.NewFilter(viper.GetString(run), viper.GetStringSlice(tags), viper.GetStringSlice(skipTags))
 			if err != nil {
 				return err
 			}
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			if viper.GetBool(listTests) {
+				return printTests(manifests, filter)
+			}
+
+			config, _ := viper.Get("cfg").(*cfg.Configuration)
+			confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+			if err := confirm.ConfirmDestructive(manifest.DestructiveChannels(manifests)); err != nil {
+				return err
+			}
+
+			pk, err := crypto.FromHex(viper.GetString(privateKey))
 			if err != nil {
 				return err
 			}
 
-			if err := manifest.ExecuteTests(cmd.Context(), manifests, client, viper.GetString(publicKey), pk); err != nil {
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
 				return err
 			}
 
-			return nil
+			runner := manifest.NewRunner(client, viper.GetString(publicKey), pk)
+			runner.Guard = confirm.Guard
+			runner.Parallel = viper.GetInt(parallel)
+			runner.Filter = filter
+			runner.UpdateSnapshots = viper.GetBool(updateSnapshots)
+			if viper.GetBool(watch) {
+				if manifestPath == manifest.Stdin {
+					return errors.New("unable to watch a test manifest read from stdin")
+				}
+				return watchTests(cmd.Context(), runner, manifestPath, manifests)
+			}
+			runner.Report = manifest.NewReport(viper.GetBool(metrics), viper.GetString(reportFile))
+			err = runner.ExecuteTests(cmd.Context(), manifests)
+			if rerr := runner.Report.Write(); err == nil {
+				err = rerr
+			}
+			return err
 		},
 	}
-	execTest.Flags().String(testManifest, defaultTestManifestPath, "location of mazzaroth channel test manifest")
+	execTest.Flags().String(testManifest, defaultTestManifestPath, "location of mazzaroth channel test manifests, a file, directory, glob or - for stdin")
+	execTest.Flags().Bool(force, false, "allow tests to reset, pause or delete the contract of a protected channel")
+	execTest.Flags().Int(parallel, 4, "number of independent tests run at once")
+	execTest.Flags().String(run, "", "only run tests with names matching the regular expression")
+	execTest.Flags().StringSlice(tags, []string{}, "only run tests with at least one of the tags")
+	execTest.Flags().StringSlice(skipTags, []string{}, "skip tests with any of the tags")
+	execTest.Flags().Bool(listTests, false, "list the tests of the manifest and whether they would be run")
+	execTest.Flags().Bool(watch, false, "rerun the tests when the manifest, contract or abi files change")
+	execTest.Flags().Bool(metrics, false, "print a summary of the transaction metrics of each test")
+	execTest.Flags().Bool(updateSnapshots, false, "rewrite the snapshots of snapshot transactions")
+	execTest.Flags().String(reportFile, "", "write the results and transaction metrics as json to the file")
 	return execTest
 }
+
+// printTests prints the tests of the manifests along with their tags and whether they are selected
+func printTests(manifests []*manifest.Manifest, filter *manifest.Filter) error {
+	data := pterm.TableData{{"test", "tags", "run"}}
+	for _, s := range manifest.SelectTests(manifests, filter) {
+		data = append(data, []string{s.Test.Name, strings.Join(s.Test.Tags, ","), strconv.FormatBool(s.Selected)})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/m8/internal/gateway"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -29,7 +29,7 @@ func listBlocks() *cobra.Command {
 		Use:   "blocks",
 		Short: "list blocks or block headers for a given channel at a specific height",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
@@ -3,10 +3,12 @@ package channel
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kochavalabs/m8/internal/gateway"
 	"github.com/kochavalabs/m8/internal/tui"
-	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,6 +19,8 @@ const (
 	channelId      = `channel-id`
 	header         = `header`
 	blockid        = `block-id`
+	follow         = `follow`
+	interval       = `interval`
 )
 
 func lookup() *cobra.Command {
@@ -24,7 +28,7 @@ func lookup() *cobra.Command {
 		Use:   "lookup",
 		Short: "look up items on a mazzaroth node",
 	}
-	lookup.AddCommand(lookupAbi(), lookupBlock(), lookupTx(), lookupReceipt())
+	lookup.AddCommand(lookupAbi(), lookupBlock(), lookupBlockHeight(), lookupTx(), lookupReceipt())
 	return lookup
 }
 
@@ -34,7 +38,7 @@ func lookupAbi() *cobra.Command {
 		Short: "return the application binary interface (ABI) for a channel",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
@@ -62,25 +66,41 @@ func lookupBlockHeight() *cobra.Command {
 		Use:   "height",
 		Short: "return the block height of a given channel",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
 
-			height, err := client.BlockHeight(cmd.Context(), viper.GetString(channelId))
-			if err != nil {
-				return err
-			}
+			var last *xdr.BlockHeight
+			for {
+				height, err := client.BlockHeight(cmd.Context(), viper.GetString(channelId))
+				if err != nil {
+					return err
+				}
 
-			v, err := json.MarshalIndent(height, "", " ")
-			if err != nil {
-				return err
-			}
+				if last == nil || height.Height != last.Height {
+					v, err := json.MarshalIndent(height, "", " ")
+					if err != nil {
+						return err
+					}
+					fmt.Println(string(v))
+				}
+				last = height
 
-			fmt.Println(string(v))
-			return nil
+				if !viper.GetBool(follow) {
+					return nil
+				}
+
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-time.After(viper.GetDuration(interval)):
+				}
+			}
 		},
 	}
+	blockHeight.Flags().Bool(follow, false, "keep polling and print the block height each time it changes")
+	blockHeight.Flags().Duration(interval, time.Second, "polling interval used with --follow")
 	return blockHeight
 }
 
@@ -90,7 +110,7 @@ func lookupBlock() *cobra.Command {
 		Short: "look up items on a mazzaroth node",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
@@ -141,7 +161,7 @@ func lookupTx() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			addr := viper.GetString(channelAddress)
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(addr))
+			client, err := gateway.NewClient(cmd.Context(), addr)
 			if err != nil {
 				return err
 			}
@@ -169,7 +189,7 @@ func lookupReceipt() *cobra.Command {
 		Short: "lookup a receipt for a given channel by transaction id",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
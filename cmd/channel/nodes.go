@@ -0,0 +1,39 @@
+package channel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func nodes() *cobra.Command {
+	nodes := &cobra.Command{
+		Use:   "nodes",
+		Short: "show the latency and block height of each gateway node of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addresses := gateway.SplitAddresses(viper.GetString(channelAddress))
+			statuses := gateway.Probe(cmd.Context(), gateway.FromContext(cmd.Context()), addresses, viper.GetString(channelId))
+
+			table := pterm.TableData{{"address", "healthy", "latency", "height", "error"}}
+			for _, s := range statuses {
+				height := ""
+				if s.Healthy {
+					height = fmt.Sprint(s.Height)
+				}
+				table = append(table, []string{
+					s.Address,
+					fmt.Sprint(s.Healthy),
+					s.Latency.Round(time.Millisecond).String(),
+					height,
+					s.Error,
+				})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+		},
+	}
+	return nodes
+}
@@ -0,0 +1,101 @@
+package channel
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/m8/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	depth        = `depth`
+	defaultDepth = 1000
+)
+
+func pause() *cobra.Command {
+	pause := &cobra.Command{
+		Use:   "pause",
+		Short: "pause transactions going to a mazzaroth channel and wait for the result",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pauseChannel(cmd, true)
+		},
+	}
+	pause.Flags().Int(depth, defaultDepth, "number of blocks scanned when reporting the channel status")
+	return pause
+}
+
+func resume() *cobra.Command {
+	resume := &cobra.Command{
+		Use:   "resume",
+		Short: "resume transactions going to a paused mazzaroth channel and wait for the result",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pauseChannel(cmd, false)
+		},
+	}
+	resume.Flags().Int(depth, defaultDepth, "number of blocks scanned when reporting the channel status")
+	return resume
+}
+
+func pauseChannel(cmd *cobra.Command, pause bool) error {
+	action := "resume"
+	if pause {
+		action = "pause"
+	}
+	config, _ := viper.Get("cfg").(*cfg.Configuration)
+	confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+	if err := confirm.Confirm(action, viper.GetString(channelId), false); err != nil {
+		return err
+	}
+
+	client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+	if err != nil {
+		return err
+	}
+
+	pk, err := crypto.FromHex(viper.GetString(privateKey))
+	if err != nil {
+		return err
+	}
+
+	tx, err := channel.PauseTx(cmd.Context(), client, viper.GetString(channelId), viper.GetString(publicKey), pk, pause)
+	if err != nil {
+		return err
+	}
+
+	channelCmd := tui.ChannelPauseStatus(cmd.Context(), client, tx, viper.GetInt(depth))
+	channelModel := tui.NewChannelModel(channelCmd)
+
+	if err := tea.NewProgram(channelModel).Start(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func status() *cobra.Command {
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "summarize the paused state, height, contract version and abi hash of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
+				return err
+			}
+
+			channelCmd := tui.ChannelStatusLookup(cmd.Context(), client, viper.GetString(channelId), viper.GetInt(depth))
+			channelModel := tui.NewChannelModel(channelCmd)
+
+			if err := tea.NewProgram(channelModel).Start(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+	status.Flags().Int(depth, defaultDepth, "number of blocks scanned for pause and deploy transactions")
+	return status
+}
@@ -0,0 +1,107 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/kochavalabs/m8/internal/tui"
+	"github.com/spf13/viper"
+)
+
+const watchDebounce = 200 * time.Millisecond
+
+// watchTests runs the tests and reruns the tests of the manifests affected by each change
+// to the manifest, contract or abi files, showing the results in a live view until it is
+// closed. A change to the manifest or the files it includes reloads it and reruns every test.
+func watchTests(ctx context.Context, runner *manifest.Runner, manifestPath string, manifests []*manifest.Manifest) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := manifest.WatchPaths(manifestPath, manifests)
+	changes, err := manifest.Watch(ctx, paths, watchDebounce)
+	if err != nil {
+		return err
+	}
+
+	confirmed := make(map[string]bool)
+	for _, id := range manifest.DestructiveChannels(manifests) {
+		confirmed[id] = true
+	}
+
+	events := make(chan tea.Msg)
+	send := func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+		}
+	}
+	runner.Output = &manifest.WriterOutput{W: ioutil.Discard}
+	runner.OnResult = func(result *manifest.TestResult) {
+		send(result)
+	}
+
+	go func() {
+		defer close(events)
+
+		send(tui.WatchRunMsg{})
+		send(tui.WatchDoneMsg{Err: runner.ExecuteTests(ctx, manifests)})
+		for changed := range changes {
+			affected := manifest.Affected(manifests, changed)
+			if manifestChanged(manifestPath, manifests, changed) {
+				reloaded, err := reloadManifests(manifestPath, confirmed)
+				if err != nil {
+					send(tui.WatchRunMsg{Changed: changed})
+					send(tui.WatchDoneMsg{Err: err})
+					continue
+				}
+				manifests, affected = reloaded, reloaded
+			}
+
+			send(tui.WatchRunMsg{Changed: changed})
+			send(tui.WatchDoneMsg{Err: runner.ExecuteTests(ctx, affected)})
+		}
+	}()
+
+	return tea.NewProgram(tui.NewWatchModel(paths, events)).Start()
+}
+
+// reloadManifests reads the test manifests again, rejecting resets, pauses and deletes on
+// channels that were not confirmed when the watch started.
+func reloadManifests(manifestPath string, confirmed map[string]bool) ([]*manifest.Manifest, error) {
+	manifests, err := manifest.FromFile(manifestPath, "test", manifest.WithEnvironment(viper.GetString(environment)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range manifest.DestructiveChannels(manifests) {
+		if !confirmed[id] {
+			return nil, fmt.Errorf("restart the watch to confirm the changes to channel %s", id)
+		}
+	}
+	return manifests, nil
+}
+
+// manifestChanged reports whether a manifest file of the path or any file it includes or extends changed
+func manifestChanged(manifestPath string, manifests []*manifest.Manifest, changed []string) bool {
+	sources := make(map[string]bool)
+	paths, _ := manifest.Paths(manifestPath)
+	for _, p := range paths {
+		sources[filepath.Clean(p)] = true
+	}
+	for _, m := range manifests {
+		for _, source := range m.Sources() {
+			sources[filepath.Clean(source)] = true
+		}
+	}
+	for _, p := range changed {
+		if sources[p] {
+			return true
+		}
+	}
+	return false
+}
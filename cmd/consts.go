@@ -9,9 +9,12 @@ const (
 	cfgName                       = `cfg.yaml`
 	defaultDeploymentManifestPath = `./m8/deployment.yaml`
 	defaultTestManifestPath       = `./m8/test.yaml`
+	defaultManifestDir            = `./m8`
+	defaultContractFile           = `contract.wasm`
+	defaultAbiFile                = `contract.json`
 	defaultChannelId              = `0000000000000000000000000000000000000000000000000000000000000000`
 	defaultGatewayNodeAddress     = `http://localhost:6299`
-	maxBlockExpirationRange       = 100
+	defaultDevNodeAddress         = `localhost:6299`
 
 	// Flags/Env
 	// Environment variables are expected to be ALL CAPS
@@ -32,4 +35,39 @@ const (
 	deploymentManifest = `deployment-manifest`
 	testManifest       = `test-manifest`
 	pausechannel       = `pause`
+	artifact           = `artifact`
+	contractVersion    = `contract-version`
+	yes                = `yes`
+	force              = `force`
+	manifestDir        = `manifest-dir`
+	contractFile       = `contract-file`
+	abiFile            = `abi-file`
+	listen             = `listen`
+	script             = `script`
+	blockInterval      = `block-interval`
+	checkExpiration    = `check-expiration`
+	record             = `record`
+	replay             = `replay`
+	caFile             = `ca-file`
+	certFile           = `cert-file`
+	keyFile            = `key-file`
+	insecureSkipVerify = `insecure-skip-verify`
+	httpHeader         = `http-header`
+	channelHeaders     = `channel-headers`
+	proxy              = `proxy`
+	timeout            = `timeout`
+	benchManifest      = `manifest`
+	concurrency        = `concurrency`
+	rate               = `rate`
+	jsonOutput         = `json`
+	environment        = `env`
+	manifestFile       = `file`
+	manifestType       = `type`
+	parallel           = `parallel`
+	run                = `run`
+	tags               = `tags`
+	skipTags           = `skip-tags`
+	metrics            = `metrics`
+	reportFile         = `report`
+	updateSnapshots    = `update-snapshots`
 )
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"os"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
 	"github.com/kochavalabs/m8/internal/tui"
 	"github.com/kochavalabs/mazzaroth-go"
 	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
@@ -20,12 +25,17 @@ func delete() *cobra.Command {
 }
 
 func deleteChannel() *cobra.Command {
-	channel := &cobra.Command{
+	deleteChannel := &cobra.Command{
 		Use:   "channel",
 		Short: "delete a channel contract",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			config, _ := viper.Get("cfg").(*cfg.Configuration)
+			confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+			if err := confirm.Confirm("delete", viper.GetString(channelId), true); err != nil {
+				return err
+			}
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
@@ -51,7 +61,7 @@ func deleteChannel() *cobra.Command {
 			}
 
 			tx, err := mazzaroth.Transaction(sender, cId).
-				Contract(mazzaroth.GenerateNonce(), blockHeight.Height+maxBlockExpirationRange).
+				Contract(mazzaroth.GenerateNonce(), blockHeight.Height+channel.MaxBlockExpirationRange).
 				Delete().Sign(pk)
 			if err != nil {
 				return err
@@ -67,5 +77,6 @@ func deleteChannel() *cobra.Command {
 		},
 	}
 
-	return channel
+	deleteChannel.Flags().Bool(force, false, "allow deleting a protected channel")
+	return deleteChannel
 }
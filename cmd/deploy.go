@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/m8/internal/history"
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func deploy() *cobra.Command {
+	deploy := &cobra.Command{
+		Use:   "deploy",
+		Short: "manage contract deployments on a mazzaroth channel",
+	}
+	deploy.AddCommand(deployRollback())
+	return deploy
+}
+
+func deployRollback() *cobra.Command {
+	rollback := &cobra.Command{
+		Use:   "rollback",
+		Short: "redeploy the previous contract version to a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
+			if err != nil {
+				return err
+			}
+
+			pk, err := crypto.FromHex(viper.GetString(privateKey))
+			if err != nil {
+				return err
+			}
+
+			sender, err := xdr.IDFromHexString(viper.GetString(publicKey))
+			if err != nil {
+				return err
+			}
+
+			cId, err := xdr.IDFromHexString(viper.GetString(channelId))
+			if err != nil {
+				return err
+			}
+
+			historyDir := history.Dir(viper.GetString(cfgPath))
+			historyPath := history.Path(historyDir, viper.GetString(channelId))
+			h, err := history.FromFile(historyPath)
+			if err != nil {
+				return err
+			}
+
+			artifactPath := viper.GetString(artifact)
+			if artifactPath == "" {
+				previous, err := h.Previous()
+				if err != nil {
+					return fmt.Errorf("%w, supply --%s to rollback to a specific artifact", err, artifact)
+				}
+				artifactPath = previous.Artifact
+			}
+
+			a, err := history.LoadArtifact(artifactPath)
+			if err != nil {
+				return err
+			}
+
+			version := a.Deployment.Version
+			if viper.GetString(contractVersion) != "" {
+				version = viper.GetString(contractVersion)
+			}
+
+			owner, err := xdr.IDFromHexString(a.Deployment.Owner)
+			if err != nil {
+				return err
+			}
+
+			if viper.GetBool(pausechannel) {
+				tx, err := channel.PauseTx(cmd.Context(), client, viper.GetString(channelId), viper.GetString(publicKey), pk, true)
+				if err != nil {
+					return err
+				}
+				if _, err := submitAndWait(cmd.Context(), client, tx, "pausing channel"); err != nil {
+					return err
+				}
+			}
+
+			blockHeight, err := client.BlockHeight(cmd.Context(), viper.GetString(channelId))
+			if err != nil {
+				return err
+			}
+
+			tx, err := mazzaroth.Transaction(sender, cId).
+				Contract(mazzaroth.GenerateNonce(), blockHeight.Height+channel.MaxBlockExpirationRange).
+				Deploy(owner, version, a.Abi, a.Contract).
+				Sign(pk)
+			if err != nil {
+				return err
+			}
+
+			receipt, err := submitAndWait(cmd.Context(), client, tx, "redeploying contract version "+version)
+			if err != nil {
+				if viper.GetBool(pausechannel) {
+					pterm.Warning.Println("channel has been left paused")
+				}
+				return err
+			}
+
+			if viper.GetBool(pausechannel) {
+				tx, err := channel.PauseTx(cmd.Context(), client, viper.GetString(channelId), viper.GetString(publicKey), pk, false)
+				if err != nil {
+					return err
+				}
+				if _, err := submitAndWait(cmd.Context(), client, tx, "unpausing channel"); err != nil {
+					return err
+				}
+			}
+
+			// rolling back from history removes the latest deployment, an explicit
+			// artifact is recorded as a new deployment
+			if viper.GetString(artifact) == "" {
+				h.Pop()
+				return history.ToFile(historyPath, h)
+			}
+
+			deployment := &history.Deployment{
+				ChannelID:     viper.GetString(channelId),
+				Version:       version,
+				Owner:         a.Deployment.Owner,
+				TransactionID: hex.EncodeToString(receipt.TransactionID[:]),
+			}
+			return history.Record(historyDir, deployment, a.Abi, a.Contract)
+		},
+	}
+	rollback.Flags().String(artifact, "", "artifact directory to redeploy, defaults to the previous deployment in the channel history")
+	rollback.Flags().String(contractVersion, "", "override the version of the redeployed contract")
+	rollback.Flags().Bool(pausechannel, false, "pause the channel during the redeploy")
+	return rollback
+}
+
+// submitAndWait submits a transaction and waits for a successful receipt
+func submitAndWait(ctx context.Context, client mazzaroth.Client, tx *xdr.Transaction, msg string) (*xdr.Receipt, error) {
+	spinner, err := pterm.DefaultSpinner.Start(msg + "...")
+	if err != nil {
+		return nil, err
+	}
+
+	id, receipt, err := client.TransactionSubmit(ctx, tx)
+	if err != nil {
+		spinner.Fail(msg + ": " + err.Error())
+		return nil, err
+	}
+
+	if receipt == nil {
+		receipt, err = manifest.PollForReceipt(viper.GetString(channelId), hex.EncodeToString(id[:]), client)
+		if err != nil {
+			spinner.Fail(msg + ": " + err.Error())
+			return nil, err
+		}
+	}
+
+	if receipt.Status != xdr.StatusSUCCESS {
+		spinner.Fail(msg + ": " + string(receipt.StatusInfo))
+		return nil, errors.New("transaction " + hex.EncodeToString(id[:]) + " failed with status " + receipt.Status.String())
+	}
+
+	spinner.Success(msg + ": tx id: " + hex.EncodeToString(id[:]))
+	return receipt, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/kochavalabs/m8/pkg/devnode"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func devNode() *cobra.Command {
+	devNode := &cobra.Command{
+		Use:   "devnode",
+		Short: "run an in-memory mazzaroth gateway node for offline testing",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind Cobra flags with viper
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			// Environment variables are expected to be ALL CAPS
+			viper.AutomaticEnv()
+			viper.SetEnvPrefix("m8")
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := []devnode.Option{
+				devnode.WithBlockInterval(viper.GetDuration(blockInterval)),
+				devnode.WithExpirationCheck(viper.GetBool(checkExpiration)),
+			}
+
+			if viper.GetString(script) != "" {
+				s, err := devnode.ScriptFromFile(viper.GetString(script))
+				if err != nil {
+					return err
+				}
+				opts = append(opts, devnode.WithScript(s))
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			pterm.Info.Println("devnode listening on", viper.GetString(listen))
+			return devnode.New(opts...).ListenAndServe(ctx, viper.GetString(listen))
+		},
+	}
+	devNode.Flags().String(listen, defaultDevNodeAddress, "address the devnode listens on")
+	devNode.Flags().String(script, "", "yaml file of scripted call responses")
+	devNode.Flags().Duration(blockInterval, 0, "interval blocks are produced at, by default a block is produced per transaction")
+	devNode.Flags().Bool(checkExpiration, false, "reject transactions with a block expiration number below the channel height")
+	return devNode
+}
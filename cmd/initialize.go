@@ -9,6 +9,7 @@ import (
 
 	"github.com/kochavalabs/crypto"
 	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/manifest"
 	"github.com/kochavalabs/m8/internal/tui"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -20,7 +21,7 @@ func initialize() *cobra.Command {
 		Use:   "init",
 		Short: "initialize resources",
 	}
-	init.AddCommand(initCfg())
+	init.AddCommand(initCfg(), initChannel(), initProject())
 	return init
 }
 
@@ -182,20 +183,186 @@ func initChannel() *cobra.Command {
 	initChannel := &cobra.Command{
 		Use:   "channel",
 		Short: "initialize a mazzaroth channel",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind Cobra flags with viper
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			// Environment variables are expected to be ALL CAPS
+			viper.AutomaticEnv()
+			viper.SetEnvPrefix("m8")
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Generate Key
 			pub, priv, err := crypto.GenerateEd25519KeyPair()
 			if err != nil {
 				return err
 			}
-			fmt.Println("channel address:", crypto.ToHex(pub))
+			fmt.Println("channel id:", crypto.ToHex(pub))
 			fmt.Println("channel private key:", crypto.ToHex(priv))
-			fmt.Println("channel Id:", crypto.ToHex(pub))
+
+			// the cfg is optional when initializing a channel, but one that exists must be readable
+			cliCfg, err := cfg.FromFile(viper.GetString(cfgPath))
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+				cliCfg = nil
+			}
+
+			alias := viper.GetString(channelAlias)
+			address := viper.GetString(channelAddress)
+			if address == "" {
+				address = defaultGatewayNodeAddress
+			}
+
+			// Add Channel to cfg Prompt
+			if cliCfg != nil {
+				addChannelPrompt := promptui.Prompt{
+					Label:     "Add channel to cfg at " + viper.GetString(cfgPath),
+					Default:   "y",
+					IsConfirm: true,
+				}
+				addChannel := "y"
+				if !viper.GetBool(yes) {
+					addChannel, _ = addChannelPrompt.Run()
+				}
+
+				switch strings.ToLower(addChannel) {
+				case "n":
+				default: // default case is y
+					if alias == "" && viper.GetBool(yes) {
+						alias = "default-channel"
+					}
+					if alias == "" {
+						channelAliasPrompt := promptui.Prompt{
+							Label:   "Channel Alias",
+							Default: "default-channel",
+						}
+						alias, err = channelAliasPrompt.Run()
+						if err != nil {
+							return err
+						}
+					}
+
+					if cliCfg.ContainsChannel(crypto.ToHex(pub), alias) {
+						return errors.New("channel already exists with the same id or alias")
+					}
+
+					cliCfg.Channels = append(cliCfg.Channels, &cfg.ChannelCfg{
+						Channel: &cfg.Channel{
+							ChannelAddress: address,
+							ChannelID:      crypto.ToHex(pub),
+							ChannelAlias:   alias,
+						},
+					})
+					if err := cfg.ToFile(viper.GetString(cfgPath), cliCfg); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Scaffold manifests Prompt
+			scaffoldPrompt := promptui.Prompt{
+				Label:     "Scaffold deployment and test manifests",
+				Default:   "y",
+				IsConfirm: true,
+			}
+			scaffold := "y"
+			if !viper.GetBool(yes) {
+				scaffold, _ = scaffoldPrompt.Run()
+			}
+
+			switch strings.ToLower(scaffold) {
+			case "n":
+				return nil
+			default: // default case is y
+				owner := crypto.ToHex(pub)
+				if cliCfg != nil && cliCfg.User != nil && cliCfg.User.PublicKey != "" {
+					owner = cliCfg.User.PublicKey
+				}
+				name := alias
+				if name == "" {
+					name = "contract"
+				}
+
+				if err := manifest.WriteScaffold(viper.GetString(manifestDir), &manifest.Scaffold{
+					Name: name,
+					Channel: manifest.Channel{
+						Id:           crypto.ToHex(pub),
+						Owner:        owner,
+						ContractFile: defaultContractFile,
+						AbiFile:      defaultAbiFile,
+					},
+					GatewayNode: manifest.GatewayNode{
+						Address: address,
+					},
+				}, false); err != nil {
+					return err
+				}
+				fmt.Println("manifests written to:", viper.GetString(manifestDir))
+			}
+
 			// TODO
 			// self signed cert for channel
 			// mazzaroth.io cert generate for channel
 			return nil
 		},
 	}
+	initChannel.Flags().String(channelAlias, "", "alias of the channel when added to the cfg")
+	initChannel.Flags().String(manifestDir, defaultManifestDir, "directory the deployment and test manifests are scaffolded in")
 	return initChannel
 }
+
+func initProject() *cobra.Command {
+	initProject := &cobra.Command{
+		Use:   "project",
+		Short: "scaffold deployment and test manifests for the active channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ok := viper.Get("cfg").(*cfg.Configuration)
+			if !ok || config == nil || config.User == nil {
+				return errors.New("missing configuration")
+			}
+
+			name := path.Base(viper.GetString(contractFile))
+			name = strings.TrimSuffix(name, path.Ext(name))
+			if channel, err := config.ActiveChannel(); err == nil && channel.ChannelID == viper.GetString(channelId) {
+				name = channel.ChannelAlias
+			}
+
+			scaffold := &manifest.Scaffold{
+				Name: name,
+				Channel: manifest.Channel{
+					Id:           viper.GetString(channelId),
+					Owner:        config.User.PublicKey,
+					ContractFile: viper.GetString(contractFile),
+					AbiFile:      viper.GetString(abiFile),
+				},
+				GatewayNode: manifest.GatewayNode{
+					Address: viper.GetString(channelAddress),
+				},
+			}
+
+			// generate a test per abi function when the abi is available
+			if _, err := os.Stat(viper.GetString(abiFile)); err == nil {
+				tests, err := manifest.TestsFromAbi(viper.GetString(abiFile))
+				if err != nil {
+					return err
+				}
+				scaffold.Tests = tests
+			}
+
+			if err := manifest.WriteScaffold(viper.GetString(manifestDir), scaffold, viper.GetBool(force)); err != nil {
+				return err
+			}
+			fmt.Println("manifests written to:", viper.GetString(manifestDir))
+			return nil
+		},
+	}
+	initProject.Flags().String(manifestDir, defaultManifestDir, "directory the deployment and test manifests are scaffolded in")
+	initProject.Flags().String(contractFile, defaultContractFile, "path to the compiled wasm contract")
+	initProject.Flags().String(abiFile, defaultAbiFile, "path to the contract abi, a test is generated for each abi function when the file exists")
+	initProject.Flags().Bool(force, false, "overwrite existing manifests")
+	return initProject
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func manifests() *cobra.Command {
+	manifests := &cobra.Command{
+		Use:   "manifest",
+		Short: "work with deployment and test manifests",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind Cobra flags with viper
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			// Environment variables are expected to be ALL CAPS
+			viper.AutomaticEnv()
+			viper.SetEnvPrefix("m8")
+			return nil
+		},
+	}
+	manifests.AddCommand(render())
+	manifests.AddCommand(schema())
+	return manifests
+}
+
+func render() *cobra.Command {
+	render := &cobra.Command{
+		Use:   "render",
+		Short: "print manifests with includes, extends, defaults and environment overrides resolved",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resolved, err := manifest.Resolve(viper.GetString(manifestFile), manifest.WithEnvironment(viper.GetString(environment)))
+			if err != nil {
+				return err
+			}
+
+			encoder := yaml.NewEncoder(os.Stdout)
+			encoder.SetIndent(2)
+			defer encoder.Close()
+			rendered := 0
+			for _, m := range resolved {
+				if t := viper.GetString(manifestType); t != "" && m.Type != t {
+					continue
+				}
+				if err := encoder.Encode(m); err != nil {
+					return err
+				}
+				rendered++
+			}
+			if rendered == 0 {
+				return fmt.Errorf("no manifests found in %s", viper.GetString(manifestFile))
+			}
+			return nil
+		},
+	}
+	render.Flags().StringP(manifestFile, "f", "", "manifest file, directory, glob or - for stdin to render")
+	render.MarkFlagRequired(manifestFile)
+	render.Flags().String(manifestType, "", "only render manifests of the type, deployment or test")
+	return render
+}
+
+func schema() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "print the JSON Schema of manifest files for editor completion and validation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := manifest.Schema()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(schema))
+			return nil
+		},
+	}
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"os"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
 	"github.com/kochavalabs/m8/internal/tui"
-	"github.com/kochavalabs/mazzaroth-go"
-	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -21,39 +24,31 @@ func pause() *cobra.Command {
 
 func pauseChannel() *cobra.Command {
 	pauseChannel := &cobra.Command{
-		Use:   "channel",
-		Short: "pause or unpause a channel contract",
+		Use:        "channel",
+		Short:      "pause or unpause a channel contract",
+		Deprecated: "use m8 channel pause or m8 channel resume instead",
 		RunE: func(cmd *cobra.Command, args []string) error {
-
-			pk, err := crypto.FromHex(viper.GetString(privateKey))
-			if err != nil {
-				return err
-			}
-
-			sender, err := xdr.IDFromHexString(viper.GetString(publicKey))
-			if err != nil {
-				return err
+			action := "unpause"
+			if viper.GetBool(pausechannel) {
+				action = "pause"
 			}
-
-			cId, err := xdr.IDFromHexString(viper.GetString(channelId))
-			if err != nil {
+			config, _ := viper.Get("cfg").(*cfg.Configuration)
+			confirm := channel.NewConfirmer(config, viper.GetString(channelAddress), viper.GetBool(force), viper.GetBool(yes), os.Stdin, os.Stdout)
+			if err := confirm.Confirm(action, viper.GetString(channelId), false); err != nil {
 				return err
 			}
 
-			client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(viper.GetString(channelAddress)))
+			client, err := gateway.NewClient(cmd.Context(), viper.GetString(channelAddress))
 			if err != nil {
 				return err
 			}
 
-			blockHeight, err := client.BlockHeight(cmd.Context(), viper.GetString(channelId))
+			pk, err := crypto.FromHex(viper.GetString(privateKey))
 			if err != nil {
 				return err
 			}
 
-			tx, err := mazzaroth.Transaction(sender, cId).
-				Contract(mazzaroth.GenerateNonce(), blockHeight.Height+maxBlockExpirationRange).
-				Pause(viper.GetBool(pausechannel)).
-				Sign(pk)
+			tx, err := channel.PauseTx(cmd.Context(), client, viper.GetString(channelId), viper.GetString(publicKey), pk, viper.GetBool(pausechannel))
 			if err != nil {
 				return err
 			}
@@ -67,6 +62,6 @@ func pauseChannel() *cobra.Command {
 			return nil
 		},
 	}
-	pauseChannel.Flags().Bool(pausechannel, false, "pause transactions from being sent")
+	pauseChannel.Flags().Bool(pausechannel, true, "pause transactions from being sent, set to false to unpause")
 	return pauseChannel
 }
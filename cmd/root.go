@@ -4,18 +4,33 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/elewis787/boa"
 	"github.com/kochavalabs/m8/cmd/channel"
 	"github.com/kochavalabs/m8/cmd/config"
 	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/mazzaroth-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
 )
 
 func Execute() error {
+	return ExecuteWith(func(address string) (mazzaroth.Client, error) {
+		opts, err := connectionOptions()
+		if err != nil {
+			return nil, err
+		}
+		return gateway.PoolFactory(gateway.NewFactory(opts))(address)
+	})
+}
+
+// ExecuteWith runs the root command with every gateway client created by factory
+func ExecuteWith(factory gateway.Factory) error {
 	// root command entry to application
 	rootCmd := &cobra.Command{
 		Use:     "m8",
@@ -56,9 +71,31 @@ func Execute() error {
 				if err != nil {
 					return err
 				}
-				viper.Set(channelAddress, channel.ChannelAddress)
+				viper.Set(channelAddress, strings.Join(channel.Addresses(), ","))
 			}
 
+			// Set connection options from the cfg channel if not set
+			channel := config.LookupChannel(viper.GetString(channelId), viper.GetString(channelAddress))
+			if !viper.IsSet(caFile) {
+				viper.Set(caFile, channel.CAFile)
+			}
+			if !viper.IsSet(certFile) {
+				viper.Set(certFile, channel.CertFile)
+			}
+			if !viper.IsSet(keyFile) {
+				viper.Set(keyFile, channel.KeyFile)
+			}
+			if !viper.IsSet(insecureSkipVerify) {
+				viper.Set(insecureSkipVerify, channel.InsecureSkipVerify)
+			}
+			if !viper.IsSet(proxy) {
+				viper.Set(proxy, channel.Proxy)
+			}
+			if !viper.IsSet(timeout) {
+				viper.Set(timeout, channel.Timeout)
+			}
+			viper.Set(channelHeaders, channel.Headers)
+
 			if !viper.IsSet(privateKey) {
 				viper.Set(privateKey, config.User.PrivateKey)
 			}
@@ -76,6 +113,11 @@ func Execute() error {
 		show(),
 		pause(),
 		delete(),
+		benchmark(),
+		deploy(),
+		devNode(),
+		manifests(),
+		apply(),
 		channel.ChannelCmdChain(),
 		config.ConfigurationCmdChain())
 
@@ -95,9 +137,22 @@ func Execute() error {
 	rootCmd.SetUsageFunc(b.UsageFunc)
 	rootCmd.PersistentFlags().String(cfgPath, dir+cfgDir+cfgName, "location of the mazzaroth config file")
 	rootCmd.PersistentFlags().String(channelId, "", "defaults to the active channel id in the cfg")
-	rootCmd.PersistentFlags().String(channelAddress, "", "defaults to active channel address in the cfg")
-
-	ctx, cancel := context.WithCancel(context.Background())
+	rootCmd.PersistentFlags().String(environment, "", "environment whose overrides are applied to manifests")
+	rootCmd.PersistentFlags().String(channelAddress, "", "defaults to active channel addresses in the cfg, comma separated for multiple gateway nodes")
+	rootCmd.PersistentFlags().Bool(yes, false, "skip confirmation prompts")
+	rootCmd.PersistentFlags().String(caFile, "", "ca bundle used to verify the gateway node, defaults to the channel ca-file in the cfg")
+	rootCmd.PersistentFlags().String(certFile, "", "client certificate for mutual tls, defaults to the channel cert-file in the cfg")
+	rootCmd.PersistentFlags().String(keyFile, "", "client certificate key for mutual tls, defaults to the channel key-file in the cfg")
+	rootCmd.PersistentFlags().Bool(insecureSkipVerify, false, "skip verification of the gateway node certificate")
+	rootCmd.PersistentFlags().StringArray(httpHeader, []string{}, "extra http header sent to the gateway node as 'Key: Value', added to the channel headers in the cfg")
+	rootCmd.PersistentFlags().String(proxy, "", "proxy url used to reach the gateway node, defaults to the channel proxy in the cfg")
+	rootCmd.PersistentFlags().Duration(timeout, 0, "gateway request timeout, defaults to the channel timeout in the cfg")
+
+	rootCmd.PersistentFlags().String(record, "", "record every gateway request and response into a cassette file")
+	rootCmd.PersistentFlags().String(replay, "", "serve gateway responses from a cassette file instead of the network")
+
+	cassette := &gateway.Cassette{}
+	ctx, cancel := context.WithCancel(gateway.WithFactory(context.Background(), cassetteFactory(factory, cassette)))
 	errGrp, errctx := errgroup.WithContext(ctx)
 	errGrp.Go(func() error {
 		defer cancel()
@@ -106,5 +161,54 @@ func Execute() error {
 		}
 		return nil
 	})
-	return errGrp.Wait()
+	err = errGrp.Wait()
+
+	// the cassette is written even if the command failed so the failure can be replayed
+	if path := viper.GetString(record); path != "" && len(cassette.Interactions) > 0 {
+		if cerr := cassette.ToFile(path); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// cassetteFactory wraps factory so that clients record or replay gateway interactions when
+// the --record or --replay flags are set. The flags are read when a client is created as
+// they are only bound once the command is executing.
+func cassetteFactory(factory gateway.Factory, cassette *gateway.Cassette) gateway.Factory {
+	var once sync.Once
+	var loadErr error
+	return func(address string) (mazzaroth.Client, error) {
+		if path := viper.GetString(replay); path != "" {
+			once.Do(func() {
+				loadErr = cassette.Load(path)
+			})
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return cassette.Replay()(address)
+		}
+		if viper.GetString(record) != "" {
+			return cassette.Record(factory)(address)
+		}
+		return factory(address)
+	}
+}
+
+// connectionOptions returns the gateway connection options set by flags or the cfg channel
+func connectionOptions() (*gateway.Options, error) {
+	headers, err := gateway.ParseHeaders(viper.GetStringMapString(channelHeaders), viper.GetStringSlice(httpHeader))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gateway.Options{
+		CAFile:             viper.GetString(caFile),
+		CertFile:           viper.GetString(certFile),
+		KeyFile:            viper.GetString(keyFile),
+		InsecureSkipVerify: viper.GetBool(insecureSkipVerify),
+		Headers:            headers,
+		Proxy:              viper.GetString(proxy),
+		Timeout:            viper.GetDuration(timeout),
+	}, nil
 }
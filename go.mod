@@ -7,13 +7,16 @@ require (
 	github.com/charmbracelet/bubbletea v0.20.0
 	github.com/charmbracelet/lipgloss v0.5.0
 	github.com/elewis787/boa v0.1.0
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/kochavalabs/crypto v0.1.3
 	github.com/kochavalabs/mazzaroth-go v0.8.5
 	github.com/kochavalabs/mazzaroth-xdr v0.8.1
 	github.com/manifoldco/promptui v0.8.0
+	github.com/pelletier/go-toml v1.9.4
 	github.com/pterm/pterm v0.12.34
 	github.com/spf13/cobra v1.4.0
 	github.com/spf13/viper v1.9.0
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -0,0 +1,295 @@
+// Package bench generates load against a mazzaroth channel by submitting call transactions
+// concurrently and measuring their latency until a receipt is available.
+package bench
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/manifest"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const (
+	heightPollInterval = 200 * time.Millisecond
+)
+
+// Call is a function call submitted by the benchmark
+type Call struct {
+	Function string
+	Args     []string
+}
+
+// Config configures a benchmark run
+type Config struct {
+	ChannelID string
+	Sender    string
+	PrivKey   ed25519.PrivateKey
+	// Calls are submitted round robin until Total transactions have been sent
+	Calls       []*Call
+	Total       int
+	Concurrency int
+	// Rate limits submits per second, zero is unlimited
+	Rate float64
+}
+
+// Latency summarizes a set of durations
+type Latency struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Report is the result of a benchmark run
+type Report struct {
+	Total         int            `json:"total"`
+	Succeeded     int            `json:"succeeded"`
+	SubmitErrors  int            `json:"submitErrors"`
+	ReceiptErrors int            `json:"receiptErrors"`
+	Statuses      map[string]int `json:"statuses"`
+	Duration      time.Duration  `json:"duration"`
+	Throughput    float64        `json:"throughput"`
+	Submit        Latency        `json:"submitLatency"`
+	Receipt       Latency        `json:"receiptLatency"`
+	// InclusionBlocks is the mean and max number of blocks between submit and receipt
+	InclusionBlocksMean float64  `json:"inclusionBlocksMean"`
+	InclusionBlocksMax  uint64   `json:"inclusionBlocksMax"`
+	Errors              []string `json:"errors,omitempty"`
+}
+
+type result struct {
+	submit    time.Duration
+	receipt   time.Duration
+	blocks    uint64
+	status    xdr.Status
+	submitErr error
+	rcptErr   error
+}
+
+// heightTracker polls the channel block height in the background
+type heightTracker struct {
+	mu     sync.Mutex
+	height uint64
+}
+
+func (h *heightTracker) get() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.height
+}
+
+func (h *heightTracker) run(ctx context.Context, client mazzaroth.Client, channelId string) {
+	ticker := time.NewTicker(heightPollInterval)
+	defer ticker.Stop()
+	for {
+		if height, err := client.BlockHeight(ctx, channelId); err == nil {
+			h.mu.Lock()
+			h.height = height.Height
+			h.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run submits the configured transactions and waits for their receipts, which are polled
+// for the same way the manifest runners do.
+func Run(ctx context.Context, client mazzaroth.Client, cfg *Config) (*Report, error) {
+	if len(cfg.Calls) == 0 {
+		return nil, errors.New("no calls to benchmark")
+	}
+	if cfg.Total < 1 {
+		return nil, errors.New("no transactions to submit")
+	}
+	if cfg.Concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+
+	senderId, err := xdr.IDFromHexString(cfg.Sender)
+	if err != nil {
+		return nil, err
+	}
+	channelId, err := xdr.IDFromHexString(cfg.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := client.BlockHeight(ctx, cfg.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	tracker := &heightTracker{height: height.Height}
+	trackerCtx, stopTracker := context.WithCancel(ctx)
+	defer stopTracker()
+	go tracker.run(trackerCtx, client, cfg.ChannelID)
+
+	sign := func(call *Call) (*xdr.Transaction, error) {
+		args := make([]xdr.Argument, 0, len(call.Args))
+		for _, a := range call.Args {
+			args = append(args, xdr.Argument(a))
+		}
+		return mazzaroth.Transaction(senderId, channelId).
+			Call(manifest.GenerateNonce(), tracker.get()+channel.MaxBlockExpirationRange).
+			Function(call.Function).
+			Arguments(args...).
+			Sign(cfg.PrivKey)
+	}
+
+	jobs := make(chan *Call)
+	results := make(chan *result, cfg.Total)
+	wg := sync.WaitGroup{}
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for call := range jobs {
+				results <- execute(ctx, client, cfg, tracker, sign, call)
+			}
+		}()
+	}
+
+	start := time.Now()
+	var limiter <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+dispatch:
+	for i := 0; i < cfg.Total; i++ {
+		if limiter != nil {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-limiter:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- cfg.Calls[i%len(cfg.Calls)]:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	return report(results, time.Since(start)), nil
+}
+
+func execute(ctx context.Context, client mazzaroth.Client, cfg *Config, tracker *heightTracker, sign func(*Call) (*xdr.Transaction, error), call *Call) *result {
+	r := &result{}
+	tx, err := sign(call)
+	if err != nil {
+		r.submitErr = err
+		return r
+	}
+
+	submitHeight := tracker.get()
+	start := time.Now()
+	id, receipt, err := client.TransactionSubmit(ctx, tx)
+	r.submit = time.Since(start)
+	if err != nil {
+		r.submitErr = err
+		return r
+	}
+
+	if receipt == nil {
+		receipt, err = manifest.PollForReceipt(cfg.ChannelID, hex.EncodeToString(id[:]), client)
+		if err != nil {
+			r.rcptErr = err
+			return r
+		}
+	}
+	r.receipt = time.Since(start)
+	r.status = receipt.Status
+	if h := tracker.get(); h > submitHeight {
+		r.blocks = h - submitHeight
+	}
+	return r
+}
+
+func report(results <-chan *result, duration time.Duration) *Report {
+	rpt := &Report{
+		Statuses: make(map[string]int),
+		Duration: duration,
+	}
+	submits := make([]time.Duration, 0)
+	receipts := make([]time.Duration, 0)
+	var blocks uint64
+	errs := make(map[string]bool)
+	for r := range results {
+		rpt.Total++
+		switch {
+		case r.submitErr != nil:
+			rpt.SubmitErrors++
+			errs[r.submitErr.Error()] = true
+		case r.rcptErr != nil:
+			rpt.ReceiptErrors++
+			submits = append(submits, r.submit)
+			errs[r.rcptErr.Error()] = true
+		default:
+			submits = append(submits, r.submit)
+			receipts = append(receipts, r.receipt)
+			rpt.Statuses[r.status.String()]++
+			if r.status == xdr.StatusSUCCESS {
+				rpt.Succeeded++
+			}
+			blocks += r.blocks
+			if r.blocks > rpt.InclusionBlocksMax {
+				rpt.InclusionBlocksMax = r.blocks
+			}
+		}
+	}
+
+	if len(receipts) > 0 {
+		rpt.InclusionBlocksMean = float64(blocks) / float64(len(receipts))
+	}
+	if duration > 0 {
+		rpt.Throughput = float64(len(receipts)) / duration.Seconds()
+	}
+	rpt.Submit = latency(submits)
+	rpt.Receipt = latency(receipts)
+	for e := range errs {
+		rpt.Errors = append(rpt.Errors, e)
+	}
+	sort.Strings(rpt.Errors)
+	return rpt
+}
+
+func latency(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	percentile := func(p float64) time.Duration {
+		i := int(float64(len(durations)-1) * p)
+		return durations[i]
+	}
+	return Latency{
+		Min:  durations[0],
+		Mean: total / time.Duration(len(durations)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  durations[len(durations)-1],
+	}
+}
@@ -2,6 +2,8 @@ package cfg
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 type Configuration struct {
@@ -22,10 +24,25 @@ type ChannelCfg struct {
 
 type Channel struct {
 	ChannelAddress string `yaml:"channel-address"`
-	ChannelID      string `yaml:"channel-id"`
-	ChannelAlias   string `yaml:"channel-alias"`
+	// ChannelAddresses are additional gateway nodes of the channel used for failover
+	ChannelAddresses []string `yaml:"channel-addresses,omitempty"`
+	ChannelID        string   `yaml:"channel-id"`
+	ChannelAlias     string   `yaml:"channel-alias"`
+	Protected        bool     `yaml:"protected,omitempty"`
+
+	// gateway connection settings
+	CAFile             string            `yaml:"ca-file,omitempty"`
+	CertFile           string            `yaml:"cert-file,omitempty"`
+	KeyFile            string            `yaml:"key-file,omitempty"`
+	InsecureSkipVerify bool              `yaml:"insecure-skip-verify,omitempty"`
+	Headers            map[string]string `yaml:"headers,omitempty"`
+	Proxy              string            `yaml:"proxy,omitempty"`
+	Timeout            time.Duration     `yaml:"timeout,omitempty"`
 }
 
+// ErrProtectedChannel is returned when a destructive operation targets a protected channel
+var ErrProtectedChannel = errors.New("channel is protected")
+
 // ActiveChannelId returns an error if a active channel is not found.
 func (c *Configuration) ActiveChannel() (*Channel, error) {
 	if c.User == nil {
@@ -50,3 +67,39 @@ func (c *Configuration) ContainsChannel(channelId string, channelAlias string) b
 	}
 	return false
 }
+
+// LookupChannel returns the cfg channel with the given id, if no channel is found
+// a channel with only the id and address set is returned.
+func (c *Configuration) LookupChannel(channelId string, channelAddress string) *Channel {
+	for _, channel := range c.Channels {
+		if channel.Channel.ChannelID == channelId {
+			return channel.Channel
+		}
+	}
+	return &Channel{
+		ChannelID:      channelId,
+		ChannelAddress: channelAddress,
+	}
+}
+
+// Guard returns ErrProtectedChannel for protected channels unless force is set
+func (c *Channel) Guard(force bool) error {
+	if c.Protected && !force {
+		return fmt.Errorf("%w: %s", ErrProtectedChannel, c.ChannelAlias)
+	}
+	return nil
+}
+
+// Addresses returns every gateway node address of the channel, starting with ChannelAddress
+func (c *Channel) Addresses() []string {
+	addresses := make([]string, 0, len(c.ChannelAddresses)+1)
+	if c.ChannelAddress != "" {
+		addresses = append(addresses, c.ChannelAddress)
+	}
+	for _, a := range c.ChannelAddresses {
+		if a != c.ChannelAddress {
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses
+}
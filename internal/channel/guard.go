@@ -0,0 +1,98 @@
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/manifoldco/promptui"
+)
+
+// Confirmer checks the protection of the channels in the cfg and asks the user to confirm
+// actions on them
+type Confirmer struct {
+	Config *cfg.Configuration
+	// Address is the gateway address of channels that are not in the cfg
+	Address string
+	// Force allows destructive actions on protected channels
+	Force bool
+	// Yes skips the confirmation prompt
+	Yes bool
+	// Prompt asks the user to confirm the action on the channel
+	Prompt func(action string, channel *cfg.Channel) error
+}
+
+// NewConfirmer returns a confirmer of the channels in the cfg, with address used for channels
+// that are not in the cfg, which prompts for confirmation on in and out
+func NewConfirmer(config *cfg.Configuration, address string, force bool, yes bool, in io.ReadCloser, out io.WriteCloser) *Confirmer {
+	return &Confirmer{
+		Config:  config,
+		Address: address,
+		Force:   force,
+		Yes:     yes,
+		Prompt: func(action string, channel *cfg.Channel) error {
+			return confirmPrompt(action, channel, in, out)
+		},
+	}
+}
+
+// confirmPrompt asks the user to confirm an action against a channel, an error is returned
+// if the action is declined.
+func confirmPrompt(action string, channel *cfg.Channel, in io.ReadCloser, out io.WriteCloser) error {
+	alias := channel.ChannelAlias
+	if alias == "" {
+		alias = "unknown"
+	}
+	prompt := promptui.Prompt{
+		Label: fmt.Sprintf("%s channel %s (id: %s, address: %s)",
+			action, alias, channel.ChannelID, channel.ChannelAddress),
+		Default:   "n",
+		IsConfirm: true,
+		Stdin:     in,
+		Stdout:    out,
+	}
+	if _, err := prompt.Run(); err != nil {
+		return errors.New(action + " cancelled")
+	}
+	return nil
+}
+
+// Confirm checks channel protection for destructive actions and prompts the user to
+// confirm the action unless Yes is set.
+func (c *Confirmer) Confirm(action string, channelId string, destructive bool) error {
+	if c.Config == nil {
+		return errors.New("missing configuration")
+	}
+
+	channel := c.Config.LookupChannel(channelId, c.Address)
+	if destructive {
+		if err := channel.Guard(c.Force); err != nil {
+			return err
+		}
+	}
+
+	if c.Yes {
+		return nil
+	}
+	return c.Prompt(action, channel)
+}
+
+// ConfirmDestructive confirms resetting, pausing or deleting the contract on each of the
+// channels, stopping at the first channel that is not confirmed.
+func (c *Confirmer) ConfirmDestructive(channelIds []string) error {
+	for _, channelId := range channelIds {
+		if err := c.Confirm("modify", channelId, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Guard checks the protection of the channel for a destructive action without prompting
+func (c *Confirmer) Guard(channelId string) error {
+	if c.Config == nil {
+		return errors.New("missing configuration")
+	}
+	return c.Config.LookupChannel(channelId, c.Address).Guard(c.Force)
+}
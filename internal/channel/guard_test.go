@@ -0,0 +1,81 @@
+package channel
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/kochavalabs/m8/internal/cfg"
+)
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopWriteCloser) Close() error                { return nil }
+
+func TestConfirm(t *testing.T) {
+	config := &cfg.Configuration{Channels: []*cfg.ChannelCfg{
+		{Channel: &cfg.Channel{ChannelID: "prod", ChannelAlias: "prod", Protected: true}},
+		{Channel: &cfg.Channel{ChannelID: "dev", ChannelAlias: "dev"}},
+	}}
+
+	tests := []struct {
+		name        string
+		config      *cfg.Configuration
+		channelId   string
+		destructive bool
+		force       bool
+		yes         bool
+		input       string
+		expectedErr error
+	}{
+		{name: "missing configuration", channelId: "dev", yes: true, expectedErr: errors.New("missing configuration")},
+		{name: "protected", config: config, channelId: "prod", destructive: true, yes: true, expectedErr: cfg.ErrProtectedChannel},
+		{name: "forced", config: config, channelId: "prod", destructive: true, force: true, yes: true},
+		{name: "protected not destructive", config: config, channelId: "prod", yes: true},
+		{name: "confirmed", config: config, channelId: "dev", destructive: true, input: "y\n"},
+		{name: "declined", config: config, channelId: "dev", destructive: true, input: "n\n", expectedErr: errors.New("delete cancelled")},
+		{name: "unknown channel", config: config, channelId: "other", destructive: true, input: "y\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := ioutil.NopCloser(strings.NewReader(test.input))
+			c := NewConfirmer(test.config, "http://localhost:6299", test.force, test.yes, in, nopWriteCloser{})
+			err := c.Confirm("delete", test.channelId, test.destructive)
+			switch {
+			case test.expectedErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.expectedErr != nil && err == nil:
+				t.Errorf("expected error %v", test.expectedErr)
+			case test.expectedErr != nil && !errors.Is(err, test.expectedErr) && err.Error() != test.expectedErr.Error():
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestConfirmDestructive(t *testing.T) {
+	config := &cfg.Configuration{Channels: []*cfg.ChannelCfg{
+		{Channel: &cfg.Channel{ChannelID: "prod", ChannelAlias: "prod", Protected: true}},
+	}}
+	prompted := make([]string, 0)
+	c := &Confirmer{Config: config, Prompt: func(action string, channel *cfg.Channel) error {
+		prompted = append(prompted, action+" "+channel.ChannelID)
+		return nil
+	}}
+
+	if err := c.ConfirmDestructive([]string{"dev", "prod", "test"}); !errors.Is(err, cfg.ErrProtectedChannel) {
+		t.Errorf("expected the protected channel error, got %v", err)
+	}
+	if strings.Join(prompted, ",") != "modify dev" {
+		t.Errorf("expected a prompt for the channel before the protected channel, got %v", prompted)
+	}
+	if err := c.Guard("prod"); !errors.Is(err, cfg.ErrProtectedChannel) {
+		t.Errorf("expected the protected channel error from the guard, got %v", err)
+	}
+	if err := c.Guard("dev"); err != nil {
+		t.Errorf("unexpected guard error: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package channel
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/m8/pkg/devnode"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const (
+	statusBlockBatch = 100
+)
+
+// Status summarizes the state of a channel. The paused state and contract version are
+// found by scanning the most recent blocks of the channel for pause and deploy transactions.
+type Status struct {
+	ChannelID       string `json:"channelID"`
+	Height          uint64 `json:"height"`
+	Paused          bool   `json:"paused"`
+	PauseHeight     uint64 `json:"pauseHeight,omitempty"`
+	ContractVersion string `json:"contractVersion,omitempty"`
+	ContractHash    string `json:"contractHash,omitempty"`
+	DeployHeight    uint64 `json:"deployHeight,omitempty"`
+	AbiVersion      string `json:"abiVersion,omitempty"`
+	AbiHash         string `json:"abiHash,omitempty"`
+	ScannedBlocks   int    `json:"scannedBlocks"`
+}
+
+// LookupStatus returns the status of a channel, scanning at most depth blocks back from the current height
+func LookupStatus(ctx context.Context, client mazzaroth.Client, channelId string, depth int) (*Status, error) {
+	height, err := client.BlockHeight(ctx, channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		ChannelID: channelId,
+		Height:    height.Height,
+	}
+
+	abi, err := client.ChannelAbi(ctx, channelId)
+	if err != nil {
+		return nil, err
+	}
+	abiHash, err := AbiHash(abi)
+	if err != nil {
+		return nil, err
+	}
+	status.AbiVersion = abi.Version
+	status.AbiHash = abiHash
+
+	pauseFound := false
+	deployFound := false
+	end := int(height.Height)
+	for end >= 0 && status.ScannedBlocks < depth && !(pauseFound && deployFound) {
+		number := statusBlockBatch
+		if remaining := depth - status.ScannedBlocks; remaining < number {
+			number = remaining
+		}
+		start := end - number + 1
+		if start < 0 {
+			start = 0
+		}
+
+		blocks, err := client.BlockList(ctx, channelId, start, end-start+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		sort.Slice(blocks, func(i, j int) bool {
+			return blocks[i].Header.BlockHeight > blocks[j].Header.BlockHeight
+		})
+
+		for _, b := range blocks {
+			for i := len(b.Transactions) - 1; i >= 0; i-- {
+				category := b.Transactions[i].Data.Category
+				pause := category.Type == xdr.CategoryTypePAUSE && !pauseFound && category.Pause != nil
+				deploy := category.Type == xdr.CategoryTypeDEPLOY && !deployFound && category.Contract != nil
+				if !pause && !deploy {
+					continue
+				}
+
+				// pauses and deploys that failed did not change the channel
+				succeeded, err := transactionSucceeded(ctx, client, channelId, &b.Transactions[i])
+				if err != nil {
+					return nil, err
+				}
+				switch {
+				case !succeeded:
+					continue
+				case pause:
+					pauseFound = true
+					status.Paused = *category.Pause
+					status.PauseHeight = b.Header.BlockHeight
+				case deploy:
+					deployFound = true
+					status.ContractVersion = category.Contract.Version
+					status.ContractHash = crypto.ToHex(category.Contract.ContractHash[:])
+					status.DeployHeight = b.Header.BlockHeight
+				}
+			}
+		}
+
+		status.ScannedBlocks += end - start + 1
+		end = start - 1
+	}
+
+	return status, nil
+}
+
+// transactionSucceeded reports whether the receipt of a transaction has a success status
+func transactionSucceeded(ctx context.Context, client mazzaroth.Client, channelId string, tx *xdr.Transaction) (bool, error) {
+	id, err := devnode.TransactionID(tx)
+	if err != nil {
+		return false, err
+	}
+	receipt, err := client.ReceiptLookup(ctx, channelId, hex.EncodeToString(id[:]))
+	if err != nil {
+		return false, err
+	}
+	return receipt.Status == xdr.StatusSUCCESS, nil
+}
+
+// AbiHash returns the hex encoded sha3 hash of the xdr encoded abi
+func AbiHash(abi *xdr.Abi) (string, error) {
+	b, err := abi.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	hasher := &crypto.Sha3_256Hasher{}
+	return crypto.ToHex(hasher.Hash(b)), nil
+}
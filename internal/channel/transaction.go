@@ -0,0 +1,36 @@
+package channel
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+// MaxBlockExpirationRange is the number of blocks past the current height a transaction
+// built by m8 remains valid for
+const MaxBlockExpirationRange = 100
+
+// PauseTx builds and signs a transaction pausing or unpausing a channel
+func PauseTx(ctx context.Context, client mazzaroth.Client, channelId string, sender string, privKey ed25519.PrivateKey, pause bool) (*xdr.Transaction, error) {
+	senderId, err := xdr.IDFromHexString(sender)
+	if err != nil {
+		return nil, err
+	}
+
+	cId, err := xdr.IDFromHexString(channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHeight, err := client.BlockHeight(ctx, channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mazzaroth.Transaction(senderId, cId).
+		Contract(mazzaroth.GenerateNonce(), blockHeight.Height+MaxBlockExpirationRange).
+		Pause(pause).
+		Sign(privKey)
+}
@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+var (
+	_ mazzaroth.Client = &recorder{}
+	_ mazzaroth.Client = &replayer{}
+)
+
+// Interaction is a single recorded call made to a gateway node
+type Interaction struct {
+	Method   string          `json:"method"`
+	Args     []string        `json:"args,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Cassette holds the gateway interactions of an m8 invocation in the order they were made
+type Cassette struct {
+	mu           sync.Mutex
+	Interactions []*Interaction `json:"interactions"`
+
+	replayed map[string]int
+}
+
+type submitResponse struct {
+	ID      *xdr.ID      `json:"id,omitempty"`
+	Receipt *xdr.Receipt `json:"receipt,omitempty"`
+}
+
+// CassetteFromFile loads a cassette written by ToFile
+func CassetteFromFile(path string) (*Cassette, error) {
+	c := &Cassette{}
+	if err := c.Load(path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load replaces the interactions of the cassette with the ones written to path
+func (c *Cassette) Load(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replayed = nil
+	return json.Unmarshal(b, c)
+}
+
+// ToFile writes the recorded interactions to path
+func (c *Cassette) ToFile(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// Record returns a factory whose clients record every interaction into the cassette
+func (c *Cassette) Record(factory Factory) Factory {
+	return func(address string) (mazzaroth.Client, error) {
+		client, err := factory(address)
+		if err != nil {
+			return nil, err
+		}
+		return &recorder{client: client, cassette: c}, nil
+	}
+}
+
+// Replay returns a factory whose clients answer with the recorded interactions instead of the network
+func (c *Cassette) Replay() Factory {
+	return func(address string) (mazzaroth.Client, error) {
+		return &replayer{cassette: c}, nil
+	}
+}
+
+func (c *Cassette) record(method string, args []string, request interface{}, response interface{}, err error) {
+	i := &Interaction{
+		Method: method,
+		Args:   args,
+	}
+	if request != nil {
+		i.Request, _ = json.Marshal(request)
+	}
+	if err != nil {
+		i.Error = err.Error()
+	} else if response != nil {
+		i.Response, _ = json.Marshal(response)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, i)
+}
+
+// next returns the next unreplayed interaction with the same method and args. Submits are
+// matched on method alone as every transaction is signed with a new nonce.
+func (c *Cassette) next(method string, args []string, response interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replayed == nil {
+		c.replayed = make(map[string]int)
+	}
+
+	key := method + "/" + strings.Join(args, "/")
+	skip := c.replayed[key]
+	for _, i := range c.Interactions {
+		if i.Method != method || strings.Join(i.Args, "/") != strings.Join(args, "/") {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		c.replayed[key]++
+		if i.Error != "" {
+			return errors.New(i.Error)
+		}
+		return json.Unmarshal(i.Response, response)
+	}
+	return fmt.Errorf("no recorded interaction for %s %s", method, strings.Join(args, " "))
+}
+
+type recorder struct {
+	client   mazzaroth.Client
+	cassette *Cassette
+}
+
+func (r *recorder) BlockHeaderLookup(ctx context.Context, channelID string, blockID string) (*xdr.BlockHeader, error) {
+	v, err := r.client.BlockHeaderLookup(ctx, channelID, blockID)
+	r.cassette.record("BlockHeaderLookup", []string{channelID, blockID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) BlockHeaderList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.BlockHeader, error) {
+	v, err := r.client.BlockHeaderList(ctx, channelID, blockHeight, number)
+	r.cassette.record("BlockHeaderList", []string{channelID, fmt.Sprint(blockHeight), fmt.Sprint(number)}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) BlockHeight(ctx context.Context, channelID string) (*xdr.BlockHeight, error) {
+	v, err := r.client.BlockHeight(ctx, channelID)
+	r.cassette.record("BlockHeight", []string{channelID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) BlockLookup(ctx context.Context, channelID string, blockID string) (*xdr.Block, error) {
+	v, err := r.client.BlockLookup(ctx, channelID, blockID)
+	r.cassette.record("BlockLookup", []string{channelID, blockID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) BlockList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.Block, error) {
+	v, err := r.client.BlockList(ctx, channelID, blockHeight, number)
+	r.cassette.record("BlockList", []string{channelID, fmt.Sprint(blockHeight), fmt.Sprint(number)}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) ChannelAbi(ctx context.Context, channelID string) (*xdr.Abi, error) {
+	v, err := r.client.ChannelAbi(ctx, channelID)
+	r.cassette.record("ChannelAbi", []string{channelID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) ReceiptLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Receipt, error) {
+	v, err := r.client.ReceiptLookup(ctx, channelID, transactionID)
+	r.cassette.record("ReceiptLookup", []string{channelID, transactionID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) TransactionLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Transaction, error) {
+	v, err := r.client.TransactionLookup(ctx, channelID, transactionID)
+	r.cassette.record("TransactionLookup", []string{channelID, transactionID}, nil, v, err)
+	return v, err
+}
+
+func (r *recorder) TransactionSubmit(ctx context.Context, transaction *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	id, receipt, err := r.client.TransactionSubmit(ctx, transaction)
+	r.cassette.record("TransactionSubmit", nil, transaction, &submitResponse{ID: id, Receipt: receipt}, err)
+	return id, receipt, err
+}
+
+type replayer struct {
+	cassette *Cassette
+}
+
+func (r *replayer) BlockHeaderLookup(ctx context.Context, channelID string, blockID string) (*xdr.BlockHeader, error) {
+	v := &xdr.BlockHeader{}
+	if err := r.cassette.next("BlockHeaderLookup", []string{channelID, blockID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) BlockHeaderList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.BlockHeader, error) {
+	v := make([]xdr.BlockHeader, 0)
+	if err := r.cassette.next("BlockHeaderList", []string{channelID, fmt.Sprint(blockHeight), fmt.Sprint(number)}, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) BlockHeight(ctx context.Context, channelID string) (*xdr.BlockHeight, error) {
+	v := &xdr.BlockHeight{}
+	if err := r.cassette.next("BlockHeight", []string{channelID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) BlockLookup(ctx context.Context, channelID string, blockID string) (*xdr.Block, error) {
+	v := &xdr.Block{}
+	if err := r.cassette.next("BlockLookup", []string{channelID, blockID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) BlockList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.Block, error) {
+	v := make([]xdr.Block, 0)
+	if err := r.cassette.next("BlockList", []string{channelID, fmt.Sprint(blockHeight), fmt.Sprint(number)}, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) ChannelAbi(ctx context.Context, channelID string) (*xdr.Abi, error) {
+	v := &xdr.Abi{}
+	if err := r.cassette.next("ChannelAbi", []string{channelID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) ReceiptLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Receipt, error) {
+	v := &xdr.Receipt{}
+	if err := r.cassette.next("ReceiptLookup", []string{channelID, transactionID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) TransactionLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Transaction, error) {
+	v := &xdr.Transaction{}
+	if err := r.cassette.next("TransactionLookup", []string{channelID, transactionID}, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *replayer) TransactionSubmit(ctx context.Context, transaction *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	v := &submitResponse{}
+	if err := r.cassette.next("TransactionSubmit", nil, v); err != nil {
+		return nil, nil, err
+	}
+	return v.ID, v.Receipt, nil
+}
@@ -0,0 +1,167 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+// cassetteCall is a gateway call made while recording and again while replaying
+type cassetteCall struct {
+	name string
+	call func(c mazzaroth.Client) (interface{}, error)
+}
+
+func newCassetteCalls(t *testing.T) []cassetteCall {
+	tx := testTransaction(t)
+	txId := hex.EncodeToString(tx.Signature[:32])
+	return []cassetteCall{
+		{name: "height", call: func(c mazzaroth.Client) (interface{}, error) {
+			return c.BlockHeight(context.Background(), testChannel)
+		}},
+		{name: "abi", call: func(c mazzaroth.Client) (interface{}, error) {
+			return c.ChannelAbi(context.Background(), testChannel)
+		}},
+		{name: "missing receipt", call: func(c mazzaroth.Client) (interface{}, error) {
+			return c.ReceiptLookup(context.Background(), testChannel, txId)
+		}},
+		{name: "submit", call: func(c mazzaroth.Client) (interface{}, error) {
+			id, receipt, err := c.TransactionSubmit(context.Background(), tx)
+			return []interface{}{id, receipt}, err
+		}},
+		{name: "receipt", call: func(c mazzaroth.Client) (interface{}, error) {
+			return c.ReceiptLookup(context.Background(), testChannel, txId)
+		}},
+		{name: "block list", call: func(c mazzaroth.Client) (interface{}, error) {
+			return c.BlockHeaderList(context.Background(), testChannel, 0, 10)
+		}},
+	}
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	fake := NewFake()
+	fake.Height = &xdr.BlockHeight{Height: 7}
+	fake.Abi = &xdr.Abi{Version: "1", Functions: []xdr.FunctionSignature{{FunctionName: "get"}}}
+	fake.BlockHeaders = []xdr.BlockHeader{{BlockHeight: 1}, {BlockHeight: 2}}
+
+	recorded := &Cassette{}
+	client, err := recorded.Record(fake.Factory())("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := newCassetteCalls(t)
+	values := make([]interface{}, len(calls))
+	errs := make([]error, len(calls))
+	for i, c := range calls {
+		values[i], errs[i] = c.call(client)
+	}
+	if errs[2] == nil {
+		t.Fatal("expected the receipt lookup before the submit to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "gateway.cassette.json")
+	if err := recorded.ToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	cassette, err := CassetteFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != len(calls) {
+		t.Fatalf("expected %d interactions, got %d", len(calls), len(cassette.Interactions))
+	}
+
+	replay, err := cassette.Replay()("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range calls {
+		t.Run(c.name, func(t *testing.T) {
+			value, err := c.call(replay)
+			if errs[i] != nil {
+				if err == nil || err.Error() != errs[i].Error() {
+					t.Fatalf("expected replayed error %v, got %v", errs[i], err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, values[i]) {
+				t.Errorf("expected replayed response %+v, got %+v", values[i], value)
+			}
+		})
+	}
+}
+
+func TestCassetteReplayUnexpectedCall(t *testing.T) {
+	fake := NewFake()
+	fake.Height = &xdr.BlockHeight{Height: 7}
+	cassette := &Cassette{}
+	client, err := cassette.Record(fake.Factory())("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BlockHeight(context.Background(), testChannel); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		call func(c mazzaroth.Client) error
+	}{
+		{
+			name: "unrecorded method",
+			call: func(c mazzaroth.Client) error {
+				_, err := c.ChannelAbi(context.Background(), testChannel)
+				return err
+			},
+		},
+		{
+			name: "unrecorded args",
+			call: func(c mazzaroth.Client) error {
+				_, err := c.BlockHeight(context.Background(), strings.Repeat("3", 64))
+				return err
+			},
+		},
+		{
+			name: "more calls than recorded",
+			call: func(c mazzaroth.Client) error {
+				if _, err := c.BlockHeight(context.Background(), testChannel); err != nil {
+					t.Fatal(err)
+				}
+				_, err := c.BlockHeight(context.Background(), testChannel)
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// reloading the interactions resets what has been replayed
+			path := filepath.Join(t.TempDir(), "gateway.cassette.json")
+			if err := cassette.ToFile(path); err != nil {
+				t.Fatal(err)
+			}
+			replayed, err := CassetteFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replay, err := replayed.Replay()("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = test.call(replay)
+			if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+				t.Errorf("expected a missing interaction error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/kochavalabs/m8/pkg/devnode"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+var _ mazzaroth.Client = &Fake{}
+
+// Submit is a recorded response to a transaction submit
+type Submit struct {
+	ID      *xdr.ID
+	Receipt *xdr.Receipt
+	Err     error
+}
+
+// Fake is a mazzaroth.Client returning recorded responses, every submitted transaction
+// is kept in Submitted. Submits are answered in order, the last recorded submit response
+// is repeated, and a receipt is generated from the transaction when none is recorded.
+type Fake struct {
+	mu sync.Mutex
+
+	Height       *xdr.BlockHeight
+	Abi          *xdr.Abi
+	Blocks       []xdr.Block
+	BlockHeaders []xdr.BlockHeader
+	Receipts     map[string]*xdr.Receipt
+	Transactions map[string]*xdr.Transaction
+	Submits      []*Submit
+	Submitted    []*xdr.Transaction
+	Err          error
+
+	submitCount int
+}
+
+// NewFake returns a fake client at block height zero with no recorded responses
+func NewFake() *Fake {
+	return &Fake{
+		Height:       &xdr.BlockHeight{},
+		Receipts:     make(map[string]*xdr.Receipt),
+		Transactions: make(map[string]*xdr.Transaction),
+	}
+}
+
+// Factory returns a client factory that always returns the fake
+func (f *Fake) Factory() Factory {
+	return func(address string) (mazzaroth.Client, error) {
+		return f, nil
+	}
+}
+
+func (f *Fake) BlockHeaderLookup(ctx context.Context, channelID string, blockID string) (*xdr.BlockHeader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.BlockHeaders {
+		if id, err := devnode.BlockID(&h); err == nil && id == blockID {
+			return &h, nil
+		}
+	}
+	return nil, f.err(errors.New("missing blockheader"))
+}
+
+func (f *Fake) BlockHeaderList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.BlockHeader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headers := make([]xdr.BlockHeader, 0, number)
+	for _, h := range f.BlockHeaders {
+		if h.BlockHeight >= uint64(blockHeight) && len(headers) < number {
+			headers = append(headers, h)
+		}
+	}
+	return headers, f.err(nil)
+}
+
+func (f *Fake) BlockHeight(ctx context.Context, channelID string) (*xdr.BlockHeight, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Height == nil {
+		return nil, f.err(errors.New("missing block height"))
+	}
+	return f.Height, f.err(nil)
+}
+
+func (f *Fake) BlockLookup(ctx context.Context, channelID string, blockID string) (*xdr.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, b := range f.Blocks {
+		if id, err := devnode.BlockID(&b.Header); err == nil && id == blockID {
+			return &b, nil
+		}
+	}
+	return nil, f.err(errors.New("missing block"))
+}
+
+func (f *Fake) BlockList(ctx context.Context, channelID string, blockHeight int, number int) ([]xdr.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	blocks := make([]xdr.Block, 0, number)
+	for _, b := range f.Blocks {
+		if b.Header.BlockHeight >= uint64(blockHeight) && len(blocks) < number {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, f.err(nil)
+}
+
+func (f *Fake) ChannelAbi(ctx context.Context, channelID string) (*xdr.Abi, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Abi == nil {
+		return nil, f.err(errors.New("missing channel abi"))
+	}
+	return f.Abi, f.err(nil)
+}
+
+func (f *Fake) ReceiptLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	receipt, ok := f.Receipts[transactionID]
+	if !ok {
+		return nil, f.err(errors.New("missing receipt"))
+	}
+	return receipt, f.err(nil)
+}
+
+func (f *Fake) TransactionLookup(ctx context.Context, channelID string, transactionID string) (*xdr.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, ok := f.Transactions[transactionID]
+	if !ok {
+		return nil, f.err(errors.New("missing transaction"))
+	}
+	return tx, f.err(nil)
+}
+
+func (f *Fake) TransactionSubmit(ctx context.Context, transaction *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Submitted = append(f.Submitted, transaction)
+	if f.Err != nil {
+		return nil, nil, f.Err
+	}
+
+	submit := &Submit{}
+	if len(f.Submits) > 0 {
+		i := f.submitCount
+		if i >= len(f.Submits) {
+			i = len(f.Submits) - 1
+		}
+		submit = f.Submits[i]
+	}
+	f.submitCount++
+	if submit.Err != nil {
+		return nil, nil, submit.Err
+	}
+
+	id := submit.ID
+	if id == nil {
+		// derive a unique id from the signature of the transaction
+		generated, err := xdr.IDFromSlice(transaction.Signature[:32])
+		if err != nil {
+			return nil, nil, err
+		}
+		id = &generated
+	}
+
+	key := hex.EncodeToString(id[:])
+	f.Transactions[key] = transaction
+	if _, ok := f.Receipts[key]; !ok {
+		f.Receipts[key] = &xdr.Receipt{
+			TransactionID: *id,
+			Status:        xdr.StatusSUCCESS,
+		}
+	}
+	return id, submit.Receipt, nil
+}
+
+func (f *Fake) err(err error) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return err
+}
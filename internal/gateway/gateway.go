@@ -0,0 +1,36 @@
+// Package gateway creates the mazzaroth clients used by m8 commands to reach gateway nodes.
+package gateway
+
+import (
+	"context"
+
+	"github.com/kochavalabs/mazzaroth-go"
+)
+
+// Factory returns a client for the gateway node at address
+type Factory func(address string) (mazzaroth.Client, error)
+
+type factoryKey struct{}
+
+// NewMazzarothClient is the default Factory creating an http client for the address
+func NewMazzarothClient(address string) (mazzaroth.Client, error) {
+	return mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(address))
+}
+
+// WithFactory returns a context carrying the client factory used by commands
+func WithFactory(ctx context.Context, factory Factory) context.Context {
+	return context.WithValue(ctx, factoryKey{}, factory)
+}
+
+// FromContext returns the client factory carried by the context, or the default factory
+func FromContext(ctx context.Context) Factory {
+	if factory, ok := ctx.Value(factoryKey{}).(Factory); ok && factory != nil {
+		return factory
+	}
+	return NewMazzarothClient
+}
+
+// NewClient creates a client for address using the factory carried by the context
+func NewClient(ctx context.Context, address string) (mazzaroth.Client, error) {
+	return FromContext(ctx)(address)
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-go"
+)
+
+const (
+	defaultTimeout = 500 * time.Millisecond
+)
+
+// Options configures the http connection to a gateway node
+type Options struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+	Headers            map[string]string
+	Proxy              string
+	Timeout            time.Duration
+}
+
+// ParseHeaders merges headers given as 'Key: Value' flags over the configured headers
+func ParseHeaders(configured map[string]string, flags []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for k, v := range configured {
+		headers[k] = v
+	}
+	for _, h := range flags {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("invalid http header " + h + ", expected 'Key: Value'")
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers, nil
+}
+
+// NewFactory returns a factory creating clients that connect with the given options
+func NewFactory(opts *Options) Factory {
+	return func(address string) (mazzaroth.Client, error) {
+		httpClient, err := opts.HTTPClient()
+		if err != nil {
+			return nil, err
+		}
+		return mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(address), mazzaroth.WithHttpClient(httpClient))
+	}
+}
+
+// HTTPClient builds the http client described by the options
+func (o *Options) HTTPClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		ca, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in ca file " + o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("client certificates require both a cert file and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if len(o.Headers) > 0 {
+		roundTripper = &headerTransport{
+			headers: o.Headers,
+			next:    transport,
+		}
+	}
+
+	return &http.Client{
+		Transport: roundTripper,
+		Timeout:   timeout,
+	}, nil
+}
+
+// headerTransport adds headers to every request, such as an authorization bearer token
+type headerTransport struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.next.RoundTrip(req)
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		configured  map[string]string
+		flags       []string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "none",
+			expected: map[string]string{},
+		},
+		{
+			name:       "configured",
+			configured: map[string]string{"Authorization": "Bearer cfg"},
+			expected:   map[string]string{"Authorization": "Bearer cfg"},
+		},
+		{
+			name:     "flags trimmed",
+			flags:    []string{"X-Team:  m8 ", "Authorization: Bearer a:b"},
+			expected: map[string]string{"X-Team": "m8", "Authorization": "Bearer a:b"},
+		},
+		{
+			name:       "flags override configured",
+			configured: map[string]string{"Authorization": "Bearer cfg", "X-Team": "m8"},
+			flags:      []string{"Authorization: Bearer flag"},
+			expected:   map[string]string{"Authorization": "Bearer flag", "X-Team": "m8"},
+		},
+		{
+			name:        "missing value",
+			flags:       []string{"Authorization"},
+			expectedErr: "invalid http header Authorization",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers, err := ParseHeaders(test.configured, test.flags)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(headers, test.expected) {
+				t.Errorf("expected headers %v, got %v", test.expected, headers)
+			}
+		})
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		opts            *Options
+		expectedTimeout time.Duration
+		expectedErr     string
+	}{
+		{name: "default timeout", opts: &Options{}, expectedTimeout: defaultTimeout},
+		{name: "timeout", opts: &Options{Timeout: 2 * time.Second}, expectedTimeout: 2 * time.Second},
+		{name: "proxy", opts: &Options{Proxy: "http://localhost:3128"}, expectedTimeout: defaultTimeout},
+		{name: "missing ca file", opts: &Options{CAFile: filepath.Join(dir, "missing.pem")}, expectedErr: "no such file"},
+		{name: "ca file without certificates", opts: &Options{CAFile: notPEM}, expectedErr: "no certificates found"},
+		{name: "cert without key", opts: &Options{CertFile: notPEM}, expectedErr: "require both a cert file and a key file"},
+		{name: "key without cert", opts: &Options{KeyFile: notPEM}, expectedErr: "require both a cert file and a key file"},
+		{name: "invalid proxy", opts: &Options{Proxy: "://proxy"}, expectedErr: "missing protocol scheme"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := test.opts.HTTPClient()
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.Timeout != test.expectedTimeout {
+				t.Errorf("expected timeout %s, got %s", test.expectedTimeout, client.Timeout)
+			}
+		})
+	}
+}
+
+func TestHTTPClientHeaders(t *testing.T) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+	}))
+	defer server.Close()
+
+	client, err := (&Options{Headers: map[string]string{"Authorization": "Bearer token"}}).HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if auth := (<-received).Get("Authorization"); auth != "Bearer token" {
+		t.Errorf("expected the authorization header to be sent, got %q", auth)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("expected the caller's request to be left unchanged")
+	}
+}
@@ -0,0 +1,318 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const (
+	healthCheckTimeout = 2 * time.Second
+)
+
+var _ mazzaroth.Client = &Pool{}
+
+// NodeStatus is the result of probing a gateway node
+type NodeStatus struct {
+	Address string        `json:"address"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency"`
+	Height  uint64        `json:"height"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// SplitAddresses splits a comma separated list of gateway node addresses
+func SplitAddresses(address string) []string {
+	addresses := make([]string, 0, 1)
+	for _, a := range strings.Split(address, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses
+}
+
+// PoolFactory wraps factory so that a comma separated list of addresses returns a Pool
+// over the nodes, a single address returns the client of factory unchanged.
+func PoolFactory(factory Factory) Factory {
+	return func(address string) (mazzaroth.Client, error) {
+		addresses := SplitAddresses(address)
+		if len(addresses) <= 1 {
+			return factory(address)
+		}
+		return NewPool(factory, addresses)
+	}
+}
+
+// Probe reports the block height and latency of each gateway node for a channel
+func Probe(ctx context.Context, factory Factory, addresses []string, channelId string) []*NodeStatus {
+	statuses := make([]*NodeStatus, len(addresses))
+	wg := sync.WaitGroup{}
+	for i, address := range addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			statuses[i] = probe(ctx, factory, address, channelId)
+		}(i, address)
+	}
+	wg.Wait()
+	return statuses
+}
+
+func probe(ctx context.Context, factory Factory, address string, channelId string) *NodeStatus {
+	status := &NodeStatus{Address: address}
+	client, err := factory(address)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	height, err := client.BlockHeight(ctx, channelId)
+	status.Latency = time.Since(start)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Healthy = true
+	status.Height = height.Height
+	return status
+}
+
+// IsConnectionError returns true if the gateway node could not be reached or did not respond
+func IsConnectionError(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
+
+// isUnreachable returns true if a connection to the gateway node could not be opened,
+// in which case a request is known to not have been received.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type node struct {
+	address string
+	client  mazzaroth.Client
+	down    bool
+	latency time.Duration
+}
+
+// Pool is a client over several gateway nodes of a channel. Reads fail over to the next
+// node on connection errors, and the receipt and transaction lookups of a submitted
+// transaction are routed to the node it was submitted to.
+type Pool struct {
+	mu      sync.Mutex
+	factory Factory
+	nodes   []*node
+	sticky  map[string]*node
+	// checkOnce runs the health check of the first request
+	checkOnce sync.Once
+}
+
+// NewPool returns a pool over the nodes at addresses, in order of preference
+func NewPool(factory Factory, addresses []string) (*Pool, error) {
+	if len(addresses) == 0 {
+		return nil, mazzaroth.ErrEmptyServerList
+	}
+	p := &Pool{
+		factory: factory,
+		sticky:  make(map[string]*node),
+	}
+	for _, address := range addresses {
+		client, err := factory(address)
+		if err != nil {
+			return nil, err
+		}
+		p.nodes = append(p.nodes, &node{address: address, client: client})
+	}
+	return p, nil
+}
+
+// HealthCheck probes every node, marking unreachable nodes as down and preferring the
+// healthy nodes with the lowest latency.
+func (p *Pool) HealthCheck(ctx context.Context, channelId string) []*NodeStatus {
+	statuses := p.healthCheck(ctx, channelId)
+	// requests after an explicit health check do not check again
+	p.checkOnce.Do(func() {})
+	return statuses
+}
+
+func (p *Pool) healthCheck(ctx context.Context, channelId string) []*NodeStatus {
+	// the nodes are probed without holding the lock, which reordering the nodes requires
+	p.mu.Lock()
+	nodes := append([]*node(nil), p.nodes...)
+	p.mu.Unlock()
+
+	addresses := make([]string, 0, len(nodes))
+	clients := make(map[string]mazzaroth.Client, len(nodes))
+	for _, n := range nodes {
+		addresses = append(addresses, n.address)
+		clients[n.address] = n.client
+	}
+	statuses := Probe(ctx, func(address string) (mazzaroth.Client, error) {
+		return clients[address], nil
+	}, addresses, channelId)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, s := range statuses {
+		nodes[i].down = !s.Healthy
+		nodes[i].latency = s.Latency
+	}
+	sort.SliceStable(p.nodes, func(i, j int) bool {
+		if p.nodes[i].down != p.nodes[j].down {
+			return !p.nodes[i].down
+		}
+		return p.nodes[i].latency < p.nodes[j].latency
+	})
+	return statuses
+}
+
+// ordered returns the nodes with preferred first followed by the healthy and then the down nodes
+func (p *Pool) ordered(ctx context.Context, channelId string, preferred *node) []*node {
+	p.checkOnce.Do(func() {
+		p.healthCheck(ctx, channelId)
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodes := make([]*node, 0, len(p.nodes))
+	if preferred != nil {
+		nodes = append(nodes, preferred)
+	}
+	for _, down := range []bool{false, true} {
+		for _, n := range p.nodes {
+			if n.down == down && n != preferred {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+func (p *Pool) setDown(n *node, down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.down = down
+}
+
+// read runs f against the nodes in order until a node responds
+func (p *Pool) read(ctx context.Context, channelId string, preferred *node, f func(c mazzaroth.Client) error) error {
+	var err error
+	for _, n := range p.ordered(ctx, channelId, preferred) {
+		err = f(n.client)
+		if err == nil || !IsConnectionError(err) {
+			p.setDown(n, false)
+			return err
+		}
+		p.setDown(n, true)
+	}
+	return err
+}
+
+func (p *Pool) stickyNode(transactionID string) *node {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sticky[transactionID]
+}
+
+func (p *Pool) BlockHeaderLookup(ctx context.Context, channelID string, blockID string) (v *xdr.BlockHeader, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.BlockHeaderLookup(ctx, channelID, blockID)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) BlockHeaderList(ctx context.Context, channelID string, blockHeight int, number int) (v []xdr.BlockHeader, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.BlockHeaderList(ctx, channelID, blockHeight, number)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) BlockHeight(ctx context.Context, channelID string) (v *xdr.BlockHeight, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.BlockHeight(ctx, channelID)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) BlockLookup(ctx context.Context, channelID string, blockID string) (v *xdr.Block, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.BlockLookup(ctx, channelID, blockID)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) BlockList(ctx context.Context, channelID string, blockHeight int, number int) (v []xdr.Block, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.BlockList(ctx, channelID, blockHeight, number)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) ChannelAbi(ctx context.Context, channelID string) (v *xdr.Abi, err error) {
+	err = p.read(ctx, channelID, nil, func(c mazzaroth.Client) error {
+		v, err = c.ChannelAbi(ctx, channelID)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) ReceiptLookup(ctx context.Context, channelID string, transactionID string) (v *xdr.Receipt, err error) {
+	err = p.read(ctx, channelID, p.stickyNode(transactionID), func(c mazzaroth.Client) error {
+		v, err = c.ReceiptLookup(ctx, channelID, transactionID)
+		return err
+	})
+	return v, err
+}
+
+func (p *Pool) TransactionLookup(ctx context.Context, channelID string, transactionID string) (v *xdr.Transaction, err error) {
+	err = p.read(ctx, channelID, p.stickyNode(transactionID), func(c mazzaroth.Client) error {
+		v, err = c.TransactionLookup(ctx, channelID, transactionID)
+		return err
+	})
+	return v, err
+}
+
+// TransactionSubmit submits to the first healthy node, only failing over when a node is
+// unreachable so that a transaction is never received twice.
+func (p *Pool) TransactionSubmit(ctx context.Context, transaction *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	channelId := hex.EncodeToString(transaction.Data.ChannelID[:])
+	var err error
+	for _, n := range p.ordered(ctx, channelId, nil) {
+		var id *xdr.ID
+		var receipt *xdr.Receipt
+		id, receipt, err = n.client.TransactionSubmit(ctx, transaction)
+		if err == nil {
+			p.mu.Lock()
+			p.sticky[hex.EncodeToString(id[:])] = n
+			p.mu.Unlock()
+			return id, receipt, nil
+		}
+		if !isUnreachable(err) {
+			return nil, nil, err
+		}
+		p.setDown(n, true)
+	}
+	return nil, nil, err
+}
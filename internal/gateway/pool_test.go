@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const testChannel = "2222222222222222222222222222222222222222222222222222222222222222"
+
+var (
+	// errUnreachable is returned by a node a connection could not be opened to
+	errUnreachable = &url.Error{Op: "Post", URL: "http://a", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	// errDropped is returned by a node that closed the connection after receiving the request
+	errDropped = &url.Error{Op: "Post", URL: "http://a", Err: errors.New("EOF")}
+)
+
+// newTestPool returns a pool over two fake nodes, a and b
+func newTestPool(t *testing.T) (*Pool, *Fake, *Fake) {
+	a, b := NewFake(), NewFake()
+	fakes := map[string]*Fake{"a": a, "b": b}
+	p, err := NewPool(func(address string) (mazzaroth.Client, error) {
+		return fakes[address], nil
+	}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, a, b
+}
+
+func testTransaction(t *testing.T) *xdr.Transaction {
+	channelId, err := xdr.IDFromHexString(testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &xdr.Transaction{}
+	tx.Data.ChannelID = channelId
+	tx.Signature[0] = 1
+	return tx
+}
+
+func TestPoolFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, p *Pool, a *Fake, b *Fake)
+	}{
+		{
+			name: "read fails over on connection error",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				a.Err = errUnreachable
+				b.Height = &xdr.BlockHeight{Height: 5}
+				height, err := p.BlockHeight(context.Background(), testChannel)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if height.Height != 5 {
+					t.Errorf("expected height 5 from node b, got %d", height.Height)
+				}
+			},
+		},
+		{
+			name: "down node is tried last",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				a.Err = errUnreachable
+				b.Height = &xdr.BlockHeight{Height: 5}
+				p.HealthCheck(context.Background(), testChannel)
+
+				// a recovers but stays behind b until b fails
+				a.Err = nil
+				a.Height = &xdr.BlockHeight{Height: 1}
+				height, err := p.BlockHeight(context.Background(), testChannel)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if height.Height != 5 {
+					t.Errorf("expected height 5 from node b, got %d", height.Height)
+				}
+
+				b.Err = errUnreachable
+				height, err = p.BlockHeight(context.Background(), testChannel)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if height.Height != 1 {
+					t.Errorf("expected height 1 from node a, got %d", height.Height)
+				}
+			},
+		},
+		{
+			name: "read does not fail over on node error",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				b.Err = errUnreachable
+				p.HealthCheck(context.Background(), testChannel)
+				b.Err = nil
+				b.Abi = &xdr.Abi{Version: "1"}
+
+				if _, err := p.ChannelAbi(context.Background(), testChannel); err == nil || err.Error() != "missing channel abi" {
+					t.Errorf("expected the missing abi error of node a, got %v", err)
+				}
+			},
+		},
+		{
+			name: "every node unreachable",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				a.Err = errUnreachable
+				b.Err = errUnreachable
+				if _, err := p.BlockHeight(context.Background(), testChannel); !IsConnectionError(err) {
+					t.Errorf("expected a connection error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "receipt lookup routed to submit node",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				id, _, err := p.TransactionSubmit(context.Background(), testTransaction(t))
+				if err != nil {
+					t.Fatal(err)
+				}
+				// only the node the transaction was submitted to has its receipt
+				if _, err := p.ReceiptLookup(context.Background(), testChannel, hex.EncodeToString(id[:])); err != nil {
+					t.Errorf("expected the receipt of the submit node, got %v", err)
+				}
+				if len(a.Submitted)+len(b.Submitted) != 1 {
+					t.Errorf("expected a single submit, got %d on a and %d on b", len(a.Submitted), len(b.Submitted))
+				}
+			},
+		},
+		{
+			name: "submit fails over when unreachable",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				p.HealthCheck(context.Background(), testChannel)
+				a.Err = errUnreachable
+				id, _, err := p.TransactionSubmit(context.Background(), testTransaction(t))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := b.Transactions[hex.EncodeToString(id[:])]; !ok {
+					t.Error("expected the transaction to be submitted to node b")
+				}
+			},
+		},
+		{
+			name: "submit does not fail over once sent",
+			run: func(t *testing.T, p *Pool, a *Fake, b *Fake) {
+				b.Err = errUnreachable
+				p.HealthCheck(context.Background(), testChannel)
+				a.Err = errDropped
+				b.Err = nil
+				if _, _, err := p.TransactionSubmit(context.Background(), testTransaction(t)); err != errDropped {
+					t.Errorf("expected the error of node a, got %v", err)
+				}
+				if len(b.Submitted) != 0 {
+					t.Errorf("expected no submit to node b, got %d", len(b.Submitted))
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, a, b := newTestPool(t)
+			test.run(t, p, a, b)
+		})
+	}
+}
+
+func TestPoolConcurrentReads(t *testing.T) {
+	p, a, b := newTestPool(t)
+	a.Err = errUnreachable
+	b.Height = &xdr.BlockHeight{Height: 5}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := p.BlockHeight(context.Background(), testChannel); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			p.HealthCheck(context.Background(), testChannel)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,193 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	historyDir   = `history`
+	historyFile  = `history.yaml`
+	artifactFile = `artifact.yaml`
+	contractFile = `contract.wasm`
+	abiFile      = `abi.json`
+)
+
+// Deployment is a single successful contract deployment to a channel
+type Deployment struct {
+	ChannelID     string    `yaml:"channel-id"`
+	Version       string    `yaml:"version"`
+	Owner         string    `yaml:"owner"`
+	ContractHash  string    `yaml:"contract-hash"`
+	TransactionID string    `yaml:"transaction-id"`
+	Artifact      string    `yaml:"artifact"`
+	Timestamp     time.Time `yaml:"timestamp"`
+}
+
+// History is the ordered list of deployments made to a channel, oldest first
+type History struct {
+	Deployments []*Deployment `yaml:"deployments"`
+}
+
+// Artifact holds everything required to redeploy a contract
+type Artifact struct {
+	Deployment *Deployment
+	Abi        *xdr.Abi
+	Contract   []byte
+}
+
+// Path returns the location of the history file for a channel within the history directory
+func Path(dir string, channelId string) string {
+	return path.Join(dir, channelId, historyFile)
+}
+
+// FromFile loads the deployment history at a given path, a missing file is treated as an empty history
+func FromFile(path string) (*History, error) {
+	historyfile, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &History{}, nil
+		}
+		return nil, err
+	}
+	h := &History{}
+	if err := yaml.Unmarshal(historyfile, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func ToFile(filePath string, h *History) error {
+	b, err := yaml.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, b, 0644)
+}
+
+// Latest returns the most recent deployment
+func (h *History) Latest() (*Deployment, error) {
+	if len(h.Deployments) == 0 {
+		return nil, errors.New("no deployments recorded")
+	}
+	return h.Deployments[len(h.Deployments)-1], nil
+}
+
+// Previous returns the deployment made before the most recent one
+func (h *History) Previous() (*Deployment, error) {
+	if len(h.Deployments) < 2 {
+		return nil, errors.New("no previous deployment recorded")
+	}
+	return h.Deployments[len(h.Deployments)-2], nil
+}
+
+// Pop removes the most recent deployment, used once a rollback has been applied
+// so that the rolled back deployment becomes the latest again.
+func (h *History) Pop() {
+	if len(h.Deployments) == 0 {
+		return
+	}
+	h.Deployments = h.Deployments[:len(h.Deployments)-1]
+}
+
+// Record stores the deployed contract and abi as an artifact and appends the deployment to the channel history
+func Record(dir string, d *Deployment, abi *xdr.Abi, contract []byte) error {
+	hasher := &crypto.Sha3_256Hasher{}
+	d.ContractHash = crypto.ToHex(hasher.Hash(contract))
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now().UTC()
+	}
+
+	historyPath := Path(dir, d.ChannelID)
+	h, err := FromFile(historyPath)
+	if err != nil {
+		return err
+	}
+
+	// artifacts are named by the deployment transaction so that an index freed by a
+	// rollback never points a new deployment at the artifact of the rolled back one
+	name := d.TransactionID
+	if name == "" {
+		name = d.Timestamp.Format("20060102T150405.000000000Z")
+	}
+	d.Artifact = path.Join(dir, d.ChannelID, fmt.Sprintf("%s-%s", d.Version, name))
+	if err := writeArtifact(d, abi, contract); err != nil {
+		return err
+	}
+
+	h.Deployments = append(h.Deployments, d)
+	return ToFile(historyPath, h)
+}
+
+func writeArtifact(d *Deployment, abi *xdr.Abi, contract []byte) error {
+	if err := os.MkdirAll(d.Artifact, 0755); err != nil {
+		return err
+	}
+
+	meta, err := yaml.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(d.Artifact, artifactFile), meta, 0644); err != nil {
+		return err
+	}
+
+	abiJson, err := json.MarshalIndent(abi, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(d.Artifact, abiFile), abiJson, 0644); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(d.Artifact, contractFile), contract, 0644)
+}
+
+// LoadArtifact reads an artifact directory containing the contract, abi and deployment metadata
+func LoadArtifact(dir string) (*Artifact, error) {
+	meta, err := ioutil.ReadFile(path.Join(dir, artifactFile))
+	if err != nil {
+		return nil, err
+	}
+	d := &Deployment{}
+	if err := yaml.Unmarshal(meta, d); err != nil {
+		return nil, err
+	}
+
+	abiJson, err := ioutil.ReadFile(path.Join(dir, abiFile))
+	if err != nil {
+		return nil, err
+	}
+	abi := &xdr.Abi{}
+	if err := json.Unmarshal(abiJson, abi); err != nil {
+		return nil, err
+	}
+
+	contract, err := ioutil.ReadFile(path.Join(dir, contractFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Artifact{
+		Deployment: d,
+		Abi:        abi,
+		Contract:   contract,
+	}, nil
+}
+
+// Dir returns the history directory that sits alongside the m8 cfg file
+func Dir(cfgPath string) string {
+	return path.Join(path.Dir(cfgPath), historyDir)
+}
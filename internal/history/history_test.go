@@ -0,0 +1,187 @@
+package history
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const testChannel = "2222222222222222222222222222222222222222222222222222222222222222"
+
+var testAbi = &xdr.Abi{
+	Version: "1",
+	Functions: []xdr.FunctionSignature{
+		{FunctionType: xdr.FunctionTypeREAD, FunctionName: "get", Parameters: []xdr.Parameter{}, Returns: []xdr.Parameter{}},
+	},
+}
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+	contract := []byte("contract v1")
+	d := &Deployment{
+		ChannelID:     testChannel,
+		Version:       "1.0",
+		Owner:         testChannel,
+		TransactionID: "aa",
+	}
+	if err := Record(dir, d, testAbi, contract); err != nil {
+		t.Fatal(err)
+	}
+
+	hasher := &crypto.Sha3_256Hasher{}
+	if expected := crypto.ToHex(hasher.Hash(contract)); d.ContractHash != expected {
+		t.Errorf("expected contract hash %s, got %s", expected, d.ContractHash)
+	}
+	if d.Timestamp.IsZero() {
+		t.Error("expected the deployment to be timestamped")
+	}
+	if expected := path.Join(dir, testChannel, "1.0-aa"); d.Artifact != expected {
+		t.Errorf("expected artifact %s, got %s", expected, d.Artifact)
+	}
+
+	a, err := LoadArtifact(d.Artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a.Contract) != string(contract) {
+		t.Errorf("expected the artifact contract %q, got %q", contract, a.Contract)
+	}
+	if !reflect.DeepEqual(a.Abi, testAbi) {
+		t.Errorf("expected the artifact abi %+v, got %+v", testAbi, a.Abi)
+	}
+	if a.Deployment.Version != "1.0" || a.Deployment.ContractHash != d.ContractHash {
+		t.Errorf("expected the artifact deployment %+v, got %+v", d, a.Deployment)
+	}
+
+	if _, err := LoadArtifact(path.Join(dir, testChannel, "missing")); err == nil {
+		t.Error("expected loading a missing artifact to fail")
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := Path(dir, testChannel)
+
+	h, err := FromFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Deployments) != 0 {
+		t.Fatalf("expected a missing history to be empty, got %d deployments", len(h.Deployments))
+	}
+
+	timestamp := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, v := range []string{"1", "2", "3"} {
+		d := &Deployment{ChannelID: testChannel, Version: v, Timestamp: timestamp}
+		if err := Record(dir, d, testAbi, []byte("contract "+v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err = FromFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := make([]string, 0, len(h.Deployments))
+	artifacts := make(map[string]bool)
+	for _, d := range h.Deployments {
+		versions = append(versions, d.Version)
+		artifacts[d.Artifact] = true
+		if !d.Timestamp.Equal(timestamp) {
+			t.Errorf("expected timestamp %s, got %s", timestamp, d.Timestamp)
+		}
+	}
+	if !reflect.DeepEqual(versions, []string{"1", "2", "3"}) {
+		t.Errorf("expected deployments oldest first, got %v", versions)
+	}
+	if len(artifacts) != 3 {
+		t.Errorf("expected an artifact per deployment, got %v", artifacts)
+	}
+
+	h.Pop()
+	if err := ToFile(historyPath, h); err != nil {
+		t.Fatal(err)
+	}
+	h, err = FromFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Deployments) != 2 {
+		t.Errorf("expected 2 deployments after a pop, got %d", len(h.Deployments))
+	}
+}
+
+func TestRollbackTarget(t *testing.T) {
+	tests := []struct {
+		name             string
+		versions         []string
+		expectedLatest   string
+		expectedPrevious string
+	}{
+		{
+			name: "no deployments",
+		},
+		{
+			name:           "no previous deployment",
+			versions:       []string{"1"},
+			expectedLatest: "1",
+		},
+		{
+			name:             "previous deployment",
+			versions:         []string{"1", "2"},
+			expectedLatest:   "2",
+			expectedPrevious: "1",
+		},
+		{
+			name:             "deployment before the latest",
+			versions:         []string{"1", "2", "3"},
+			expectedLatest:   "3",
+			expectedPrevious: "2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &History{}
+			for _, v := range test.versions {
+				h.Deployments = append(h.Deployments, &Deployment{Version: v})
+			}
+
+			latest, err := h.Latest()
+			if test.expectedLatest == "" {
+				if err == nil || !strings.Contains(err.Error(), "no deployments recorded") {
+					t.Fatalf("expected a no deployments error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if latest.Version != test.expectedLatest {
+				t.Errorf("expected latest version %s, got %s", test.expectedLatest, latest.Version)
+			}
+
+			previous, err := h.Previous()
+			if test.expectedPrevious == "" {
+				if err == nil || !strings.Contains(err.Error(), "no previous deployment recorded") {
+					t.Fatalf("expected a no previous deployment error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if previous.Version != test.expectedPrevious {
+				t.Errorf("expected rollback to version %s, got %s", test.expectedPrevious, previous.Version)
+			}
+
+			// once the rollback is applied the previous deployment becomes the latest
+			h.Pop()
+			if latest, err := h.Latest(); err != nil || latest != previous {
+				t.Errorf("expected the rolled back deployment to be latest, got %+v, %v", latest, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+)
+
+// Statuses of an applied manifest
+const (
+	ApplyApplied = "applied"
+	ApplyFailed  = "failed"
+	ApplySkipped = "skipped"
+	ApplyNotRun  = "not run"
+)
+
+// ApplyResult is the outcome of applying a manifest
+type ApplyResult struct {
+	Path   string
+	Type   string
+	Status string
+	// Reason explains why the manifest failed, was skipped or was not run
+	Reason string
+}
+
+// Apply runs every manifest in order according to its type, deploying deployment manifests
+// and running the tests of test manifests. Manifests of other types are skipped and reported
+// as such rather than dropped. Applying stops at the first manifest that fails, the
+// manifests after it are reported as not run.
+func (r *Runner) Apply(ctx context.Context, manifests []*Manifest) ([]*ApplyResult, error) {
+	results := make([]*ApplyResult, 0, len(manifests))
+	var err error
+	for _, m := range manifests {
+		result := &ApplyResult{Path: m.path, Type: m.Type}
+		results = append(results, result)
+		if err != nil {
+			result.Status, result.Reason = ApplyNotRun, "an earlier manifest failed"
+			continue
+		}
+
+		switch m.Type {
+		case "deployment":
+			err = r.ExecuteDeployments(ctx, []*Manifest{m})
+		case "test":
+			err = r.ExecuteTests(ctx, []*Manifest{m})
+		default:
+			result.Status, result.Reason = ApplySkipped, "unknown type "+m.Type
+			if m.Type == "" {
+				result.Reason = "missing type"
+			}
+			r.Output.Println("skipping manifest in " + m.path + ": " + result.Reason)
+			continue
+		}
+
+		result.Status = ApplyApplied
+		if err != nil {
+			result.Status, result.Reason = ApplyFailed, err.Error()
+			err = fmt.Errorf("%s manifest in %s: %w", m.Type, m.path, err)
+		}
+	}
+	return results, err
+}
@@ -0,0 +1,247 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// testCase is a row of a table test along with the label used in the sub-test name
+type testCase struct {
+	label  string
+	values map[string]string
+}
+
+// expandTests replaces every table test of the manifest with one sub-test per row. Case
+// files are relative to the manifest file, like included and extended files.
+func (m *Manifest) expandTests() error {
+	if m.Tests == nil {
+		return nil
+	}
+
+	dir := filepath.Dir(m.path)
+	if m.path == Stdin {
+		dir = "."
+	}
+	tests := make([]*Test, 0, len(m.Tests))
+	for _, t := range m.Tests {
+		expanded, err := t.expand(dir)
+		if err != nil {
+			return fmt.Errorf("test %s: %w", t.Name, err)
+		}
+		tests = append(tests, expanded...)
+	}
+	m.Tests = tests
+	return nil
+}
+
+// expand returns a sub-test named <test>/<row> for each row of the cases and matrix of a
+// table test, with the row values substituted into the transaction templates. When both
+// cases and a matrix are declared every case is combined with every matrix row. Tests
+// without a table are returned as is. A cases file is read relative to dir.
+func (t *Test) expand(dir string) ([]*Test, error) {
+	if len(t.Cases) == 0 && t.CasesFile == "" && len(t.Matrix) == 0 {
+		return []*Test{t}, nil
+	}
+
+	cases, err := t.cases(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make([]*Test, 0, len(cases))
+	for _, c := range cases {
+		sub := *t
+		sub.Name = t.Name + "/" + c.label
+		sub.Cases, sub.CasesFile, sub.Matrix = nil, "", nil
+		sub.Transactions = make([]*Tx, 0, len(t.Transactions))
+		for _, tx := range t.Transactions {
+			expanded, err := expandTx(tx, c.values)
+			if err != nil {
+				return nil, fmt.Errorf("case %s: %w", c.label, err)
+			}
+			sub.Transactions = append(sub.Transactions, expanded)
+		}
+		tests = append(tests, &sub)
+	}
+	return tests, nil
+}
+
+// cases returns the rows of a table test, combining the cases with the matrix rows
+func (t *Test) cases(dir string) ([]*testCase, error) {
+	rows := make([]map[string]string, 0, len(t.Cases))
+	rows = append(rows, t.Cases...)
+	if t.CasesFile != "" {
+		path := t.CasesFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		loaded, err := loadCases(path)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, loaded...)
+	}
+
+	cases := make([]*testCase, 0, len(rows))
+	for i, row := range rows {
+		label := row["name"]
+		if label == "" {
+			label = strconv.Itoa(i + 1)
+		}
+		cases = append(cases, &testCase{label: label, values: row})
+	}
+
+	if len(t.Matrix) == 0 {
+		return cases, nil
+	}
+
+	matrix := matrixCases(t.Matrix)
+	if len(cases) == 0 {
+		return matrix, nil
+	}
+
+	combined := make([]*testCase, 0, len(cases)*len(matrix))
+	for _, c := range cases {
+		for _, mc := range matrix {
+			values := make(map[string]string, len(c.values)+len(mc.values))
+			for k, v := range c.values {
+				values[k] = v
+			}
+			for k, v := range mc.values {
+				values[k] = v
+			}
+			combined = append(combined, &testCase{label: c.label + "," + mc.label, values: values})
+		}
+	}
+	return combined, nil
+}
+
+// matrixCases returns every combination of the matrix values, iterating the keys in sorted order
+func matrixCases(matrix map[string][]string) []*testCase {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cases := []*testCase{{values: map[string]string{}}}
+	for _, k := range keys {
+		next := make([]*testCase, 0, len(cases)*len(matrix[k]))
+		for _, c := range cases {
+			for _, v := range matrix[k] {
+				values := make(map[string]string, len(c.values)+1)
+				for ck, cv := range c.values {
+					values[ck] = cv
+				}
+				values[k] = v
+
+				label := k + "=" + v
+				if c.label != "" {
+					label = c.label + "," + label
+				}
+				next = append(next, &testCase{label: label, values: values})
+			}
+		}
+		cases = next
+	}
+	return cases
+}
+
+// loadCases reads the rows of a csv file with a header row or a json array of objects
+func loadCases(path string) ([]map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		rows := make([]map[string]string, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(records[0]))
+			for i, key := range records[0] {
+				row[key] = record[i]
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	case ".json":
+		objects := make([]map[string]interface{}, 0)
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&objects); err != nil {
+			return nil, err
+		}
+		rows := make([]map[string]string, 0, len(objects))
+		for _, o := range objects {
+			row := make(map[string]string, len(o))
+			for k, v := range o {
+				row[k] = fmt.Sprint(v)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	default:
+		return nil, errors.New("unsupported cases file " + path + ", expected .csv or .json")
+	}
+}
+
+// expandTx substitutes the row values into the function, args and expected result of a transaction
+func expandTx(tx *Tx, values map[string]string) (*Tx, error) {
+	if tx.Tx == nil {
+		return tx, nil
+	}
+
+	expand := func(text string) (string, error) {
+		if !strings.Contains(text, "{{") {
+			return text, nil
+		}
+		tmpl, err := template.New("case").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, values); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	expanded := *tx.Tx
+	var err error
+	if expanded.Function, err = expand(tx.Tx.Function); err != nil {
+		return nil, err
+	}
+	expanded.Args = make([]string, 0, len(tx.Tx.Args))
+	for _, a := range tx.Tx.Args {
+		arg, err := expand(a)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Args = append(expanded.Args, arg)
+	}
+	if tx.Tx.Receipt != nil {
+		receipt := *tx.Tx.Receipt
+		if receipt.Result, err = expand(tx.Tx.Receipt.Result); err != nil {
+			return nil, err
+		}
+		expanded.Receipt = &receipt
+	}
+	return &Tx{Tx: &expanded}, nil
+}
@@ -0,0 +1,69 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCasesFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(sub, "cases.csv"):  "name,a\none,1\ntwo,2\n",
+		filepath.Join(sub, "cases.json"): `[{"name": "three", "a": "3"}]`,
+		filepath.Join(dir, "root.csv"):   "name,a\nroot,0\n",
+	}
+	for path, data := range files {
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		casesFile   string
+		expected    []string
+		expectedErr string
+	}{
+		{name: "csv next to the manifest", casesFile: "cases.csv", expected: []string{"add/one", "add/two"}},
+		{name: "json next to the manifest", casesFile: "cases.json", expected: []string{"add/three"}},
+		{name: "parent directory", casesFile: "../root.csv", expected: []string{"add/root"}},
+		{name: "absolute path", casesFile: filepath.Join(sub, "cases.csv"), expected: []string{"add/one", "add/two"}},
+		{name: "not relative to the manifest", casesFile: "sub/cases.csv", expectedErr: "no such file or directory"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(sub, "manifest.yaml")
+			manifest := "version: 0.0.1\ntype: test\ntests:\n  - name: add\n    cases-file: " + test.casesFile + "\n    transactions:\n      - tx: {function: add, args: [\"{{.a}}\"]}\n"
+			if err := ioutil.WriteFile(path, []byte(manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			manifests, err := Resolve(path)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0)
+			for _, test := range manifests[0].Tests {
+				names = append(names, test.Name)
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("expected tests %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
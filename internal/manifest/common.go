@@ -1,5 +1,7 @@
 package manifest
 
+import "time"
+
 type Channel struct {
 	Version      string `yaml:"version,omitempty"`
 	Id           string `yaml:"id,omitempty"`
@@ -12,14 +14,26 @@ type GatewayNode struct {
 	Address string `yaml:"address,omitempty"`
 }
 
+// Tx is a step of a deployment, test or hook, which is a call transaction or one of the
+// channel operations, waits and lookups of a scenario. Exactly one of the fields is set.
 type Tx struct {
-	Tx *Transaction `yaml:"tx"`
+	Tx            *Transaction  `yaml:"tx,omitempty"`
+	Pause         *Operation    `yaml:"pause,omitempty"`
+	Unpause       *Operation    `yaml:"unpause,omitempty"`
+	Delete        *Operation    `yaml:"delete,omitempty"`
+	WaitForBlocks uint64        `yaml:"wait_for_blocks,omitempty"`
+	Sleep         time.Duration `yaml:"sleep,omitempty"`
+	Lookup        *Lookup       `yaml:"lookup,omitempty"`
 }
 
 type Transaction struct {
-	Function string   `yaml:"function,omitempty"`
-	Args     []string `yaml:"args,omitempty"`
-	Receipt  *Receipt `yaml:"receipt,omitempty"`
+	Function     string   `yaml:"function,omitempty"`
+	Args         []string `yaml:"args,omitempty"`
+	Receipt      *Receipt `yaml:"receipt,omitempty"`
+	Snapshot     bool     `yaml:"snapshot,omitempty"`
+	ExpectError  string   `yaml:"expect_error,omitempty"`
+	ExpectStatus Statuses `yaml:"expect_status,omitempty"`
+	Signer       string   `yaml:"signer,omitempty"`
 }
 
 type Receipt struct {
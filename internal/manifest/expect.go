@@ -0,0 +1,144 @@
+package manifest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+// Statuses are receipt statuses given as numbers or names such as failure, a single
+// status can be given without a list.
+type Statuses []int32
+
+func (s *Statuses) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	values := make([]string, 0)
+	if err := unmarshal(&values); err != nil {
+		value := ""
+		if err := unmarshal(&value); err != nil {
+			return err
+		}
+		values = []string{value}
+	}
+
+	statuses := make(Statuses, 0, len(values))
+	for _, v := range values {
+		status, err := parseStatus(v)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+	}
+	*s = statuses
+	return nil
+}
+
+func parseStatus(value string) (int32, error) {
+	if n, err := strconv.ParseInt(value, 10, 32); err == nil {
+		return int32(n), nil
+	}
+	name := "Status" + strings.ToUpper(strings.TrimPrefix(strings.ToLower(value), "status"))
+	for code, statusName := range xdr.StatusMap {
+		if statusName == name {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown receipt status %s", value)
+}
+
+func (s Statuses) contains(status xdr.Status) bool {
+	for _, expected := range s {
+		if xdr.Status(expected) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSubmitError compares the error of submitting a transaction against the expect_error
+// pattern of the step, an error is returned when the transaction did not fail as expected
+// or failed when it was not expected to.
+func checkSubmitError(expectError string, receipt *xdr.Receipt, err error) error {
+	if expectError == "" {
+		return err
+	}
+
+	re, rerr := regexp.Compile(expectError)
+	if rerr != nil {
+		return fmt.Errorf("invalid expect_error %q: %w", expectError, rerr)
+	}
+	if err == nil {
+		return fmt.Errorf("expected transaction error matching %q, transaction completed with status %s", expectError, receipt.Status)
+	}
+	if !re.MatchString(err.Error()) {
+		return fmt.Errorf("transaction error %q does not match %q", err, expectError)
+	}
+	return nil
+}
+
+// submitExpecting submits a transaction of a step with an expect_error pattern. A failure
+// matching the pattern is reported as expected and returns no receipt, only a transaction
+// that did not fail as expected is reported as failed.
+func (r *Runner) submitExpecting(ctx context.Context, channelId string, label string, tx *xdr.Transaction, expectError string) (*xdr.Receipt, error) {
+	if expectError == "" {
+		_, receipt, err := r.submit(ctx, channelId, label, tx)
+		return receipt, err
+	}
+
+	_, receipt, err := r.send(ctx, channelId, label, tx)
+	if cerr := checkSubmitError(expectError, receipt, err); cerr != nil {
+		r.Output.Failed(label, cerr)
+		return nil, cerr
+	}
+	r.Output.Println(label + " failed as expected: " + err.Error())
+	return nil, nil
+}
+
+// signer returns the sender id and private key a step is signed with, which is the runner
+// key unless the step names one of the manifest signers. Signer keys are hex private keys
+// or ${NAME} to read the key from the environment.
+func (r *Runner) signer(m *Manifest, signer string, senderId xdr.ID) (xdr.ID, ed25519.PrivateKey, error) {
+	if signer == "" {
+		return senderId, r.PrivKey, nil
+	}
+
+	key, ok := m.Signers[signer]
+	if !ok {
+		return xdr.ID{}, nil, fmt.Errorf("unknown signer %s", signer)
+	}
+	key, err := signerKey(signer, key)
+	if err != nil {
+		return xdr.ID{}, nil, err
+	}
+	privKey, err := crypto.FromHex(key)
+	if err != nil {
+		return xdr.ID{}, nil, fmt.Errorf("signer %s: %w", signer, err)
+	}
+	if len(privKey) != ed25519.PrivateKeySize {
+		return xdr.ID{}, nil, fmt.Errorf("signer %s: invalid private key length %d", signer, len(privKey))
+	}
+
+	id := xdr.ID{}
+	copy(id[:], ed25519.PrivateKey(privKey).Public().(ed25519.PublicKey))
+	return id, ed25519.PrivateKey(privKey), nil
+}
+
+// signerKey returns the private key of a signer, which is read from the environment when
+// it is given as ${NAME} so keys do not have to be committed with the manifest
+func signerKey(signer string, key string) (string, error) {
+	if !strings.HasPrefix(key, "${") || !strings.HasSuffix(key, "}") {
+		return key, nil
+	}
+	name := key[2 : len(key)-1]
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("signer %s: environment variable %s is not set", signer, name)
+	}
+	return value, nil
+}
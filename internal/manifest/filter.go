@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"regexp"
+)
+
+// Filter selects the tests of test manifests to run
+type Filter struct {
+	// Run matches the names of the tests to run, all tests match when nil
+	Run *regexp.Regexp
+	// Tags selects tests with at least one of the tags, all tests match when empty
+	Tags []string
+	// SkipTags excludes tests with any of the tags
+	SkipTags []string
+}
+
+// NewFilter compiles the run expression of a filter, an empty expression matches every test
+func NewFilter(run string, tags []string, skipTags []string) (*Filter, error) {
+	f := &Filter{Tags: tags, SkipTags: skipTags}
+	if run != "" {
+		re, err := regexp.Compile(run)
+		if err != nil {
+			return nil, err
+		}
+		f.Run = re
+	}
+	return f, nil
+}
+
+// Match reports whether a test is selected by the name and tag filters
+func (f *Filter) Match(t *Test) bool {
+	if f == nil {
+		return true
+	}
+	if f.Run != nil && !f.Run.MatchString(t.Name) {
+		return false
+	}
+	if len(f.Tags) > 0 && !hasTag(t, f.Tags) {
+		return false
+	}
+	return !hasTag(t, f.SkipTags)
+}
+
+func hasTag(t *Test, tags []string) bool {
+	for _, tag := range tags {
+		for _, testTag := range t.Tags {
+			if tag == testTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestSelection is a test discovered in a test manifest and whether it will be run
+type TestSelection struct {
+	Manifest *Manifest
+	Test     *Test
+	Selected bool
+}
+
+// SelectTests returns every test of the test manifests in order. Tests marked skip are never
+// selected, and when any test is marked only the tests without the marker are not selected.
+func SelectTests(manifests []*Manifest, filter *Filter) []*TestSelection {
+	only := false
+	for _, m := range manifests {
+		if m.Type != "test" {
+			continue
+		}
+		for _, t := range m.Tests {
+			if t.Only && !t.Skip {
+				only = true
+			}
+		}
+	}
+
+	selections := make([]*TestSelection, 0)
+	for _, m := range manifests {
+		if m.Type != "test" {
+			continue
+		}
+		for _, t := range m.Tests {
+			selections = append(selections, &TestSelection{
+				Manifest: m,
+				Test:     t,
+				Selected: !t.Skip && (!only || t.Only) && filter.Match(t),
+			})
+		}
+	}
+	return selections
+}
+
+// DestructiveChannels returns the ids of the channels with tests that reset the contract or
+// steps that pause or delete it, in manifest order
+func DestructiveChannels(manifests []*Manifest) []string {
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, m := range manifests {
+		if !seen[m.Channel.Id] && m.destructive() {
+			seen[m.Channel.Id] = true
+			ids = append(ids, m.Channel.Id)
+		}
+	}
+	return ids
+}
+
+// destructive reports whether a test of the manifest resets the contract or any of its
+// deploy, hook, fixture or test steps pauses or deletes it
+func (m *Manifest) destructive() bool {
+	steps := m.Hooks.steps()
+	if m.Deploy != nil {
+		steps = append(steps, m.Deploy.Transactions...)
+	}
+	for _, fixture := range m.Fixtures {
+		steps = append(steps, fixture...)
+	}
+	for _, t := range m.Tests {
+		if t.Reset {
+			return true
+		}
+		steps = append(append(steps, t.Hooks.steps()...), t.Transactions...)
+	}
+
+	for _, s := range steps {
+		if s.Pause != nil || s.Delete != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// steps returns the steps of every hook
+func (h *Hooks) steps() []*Tx {
+	steps := make([]*Tx, 0, len(h.BeforeAll)+len(h.BeforeEach)+len(h.AfterEach)+len(h.AfterAll))
+	return append(append(append(append(steps, h.BeforeAll...), h.BeforeEach...), h.AfterEach...), h.AfterAll...)
+}
@@ -0,0 +1,36 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDestructiveChannels(t *testing.T) {
+	manifest := func(id string) *Manifest {
+		return &Manifest{Channel: Channel{Id: id}}
+	}
+	deleteStep := []*Tx{{Delete: &Operation{}}}
+	pauseStep := []*Tx{{Pause: &Operation{}}}
+	unpauseStep := []*Tx{{Unpause: &Operation{}}}
+
+	reset := manifest("reset")
+	reset.Tests = []*Test{{Name: "t", Reset: true}}
+	deploy := manifest("deploy")
+	deploy.Deploy = &Deploy{Transactions: pauseStep}
+	hook := manifest("hook")
+	hook.AfterAll = deleteStep
+	fixture := manifest("fixture")
+	fixture.Fixtures = map[string][]*Tx{"f": deleteStep}
+	testHook := manifest("test-hook")
+	testHook.Tests = []*Test{{Name: "t", Hooks: Hooks{BeforeEach: pauseStep}}}
+	unpause := manifest("unpause")
+	unpause.Tests = []*Test{{Name: "t", Transactions: unpauseStep}}
+	duplicate := manifest("reset")
+	duplicate.Tests = []*Test{{Name: "t", Transactions: deleteStep}}
+
+	expected := []string{"reset", "deploy", "hook", "fixture", "test-hook"}
+	actual := DestructiveChannels([]*Manifest{reset, deploy, hook, fixture, testHook, unpause, duplicate})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected channels %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,99 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats manifest files can be written in
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// tomlLine matches the table header or key assignment a toml file starts with
+var tomlLine = regexp.MustCompile(`^(\[\[?\s*[\w."'-]+\s*\]\]?|[\w"'-][\w."' -]*=)`)
+
+// Format returns the format of a manifest file from its extension, falling back to the
+// content when the extension is not known. Content that is valid json is json, content
+// starting with a toml table header or key assignment is toml and anything else is yaml.
+func Format(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+
+	if json.Valid(data) {
+		return FormatJSON
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if tomlLine.MatchString(line) {
+			return FormatTOML
+		}
+		break
+	}
+	return FormatYAML
+}
+
+// decodeDocuments returns the mapping of each manifest of a file. A yaml file holds a
+// manifest per document and a json file a manifest or an array of manifests, a sequence
+// at the top of a yaml document is read the same way. A toml file holds a single manifest.
+func decodeDocuments(path string, data []byte) ([]*yaml.Node, error) {
+	if Format(path, data) == FormatTOML {
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		node := &yaml.Node{}
+		if err := node.Encode(tree.ToMap()); err != nil {
+			return nil, err
+		}
+		return []*yaml.Node{node}, nil
+	}
+
+	// json is read with the yaml decoder, which accepts it as flow style yaml
+	nodes := make([]*yaml.Node, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		node := &yaml.Node{}
+		if err := decoder.Decode(node); err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			return nodes, nil
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+
+		values := []*yaml.Node{node.Content[0]}
+		if node.Content[0].Kind == yaml.SequenceNode {
+			values = node.Content[0].Content
+		}
+		for _, v := range values {
+			if v.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: expected a mapping", v.Line)
+			}
+			nodes = append(nodes, v)
+		}
+	}
+}
@@ -1,23 +1,14 @@
 package manifest
 
 import (
-	"bytes"
 	"context"
-	"crypto/ed25519"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"os"
 	"time"
 
-	"github.com/pterm/pterm"
-
 	"github.com/kochavalabs/mazzaroth-go"
 	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
-	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -30,8 +21,15 @@ type Manifest struct {
 	Type        string      `yaml:"type"`
 	Channel     Channel     `yaml:"channel"`
 	GatewayNode GatewayNode `yaml:"gateway-node"`
-	Deploy      *Deploy     `yaml:"deploy"`
-	Tests       []*Test     `yaml:"tests"`
+	Deploy      *Deploy     `yaml:"deploy,omitempty"`
+	Hooks       `yaml:",inline"`
+	Fixtures    map[string][]*Tx  `yaml:"fixtures,omitempty"`
+	Signers     map[string]string `yaml:"signers,omitempty"`
+	Tests       []*Test           `yaml:"tests,omitempty"`
+
+	// path is the file the manifest was read from and sources every file it was resolved from
+	path    string
+	sources []string
 }
 
 type Deploy struct {
@@ -40,9 +38,33 @@ type Deploy struct {
 }
 
 type Test struct {
-	Name         string `yaml:"name"`
-	Reset        bool   `yaml:"reset"`
-	Transactions []*Tx  `yaml:"transactions,omitempty"`
+	Name         string   `yaml:"name"`
+	Tags         []string `yaml:"tags,omitempty"`
+	Skip         bool     `yaml:"skip,omitempty"`
+	Only         bool     `yaml:"only,omitempty"`
+	Reset        bool     `yaml:"reset,omitempty"`
+	Independent  bool     `yaml:"independent,omitempty"`
+	Hooks        `yaml:",inline"`
+	Fixtures     []string `yaml:"fixtures,omitempty"`
+	Transactions []*Tx    `yaml:"transactions,omitempty"`
+
+	// Cases, CasesFile and Matrix declare a table test, which expands its transactions
+	// into a sub-test per row when the manifest is read
+	Cases     []map[string]string `yaml:"cases,omitempty"`
+	CasesFile string              `yaml:"cases-file,omitempty"`
+	Matrix    map[string][]string `yaml:"matrix,omitempty"`
+}
+
+// Hooks are transactions run around tests. At the manifest level the all hooks run once
+// before the first and after the last test and the each hooks run around every test. At
+// the test level the all hooks run around the test and the each hooks around every
+// transaction of the test. Fixtures of the manifest included by a test run after the
+// manifest before_each hook and before the test hooks.
+type Hooks struct {
+	BeforeAll  []*Tx `yaml:"before_all,omitempty"`
+	BeforeEach []*Tx `yaml:"before_each,omitempty"`
+	AfterEach  []*Tx `yaml:"after_each,omitempty"`
+	AfterAll   []*Tx `yaml:"after_all,omitempty"`
 }
 
 func loadAbi(path string) (*xdr.Abi, error) {
@@ -67,8 +89,10 @@ func loadContract(path string) ([]byte, error) {
 	return contractFile, nil
 }
 
+// PollForReceipt looks up the receipt of a submitted transaction, retrying with a
+// linear backoff until the receipt is available or the retries are exhausted.
 // TODO must replace with WS Connection, P2P, or sync tx execution to prevent polling
-func pollForReceipt(channelId string, transactionId string, client mazzaroth.Client) (*xdr.Receipt, error) {
+func PollForReceipt(channelId string, transactionId string, client mazzaroth.Client) (*xdr.Receipt, error) {
 	retry := 0
 	for {
 		receipt, err := client.ReceiptLookup(context.Background(), channelId, transactionId)
@@ -85,257 +109,61 @@ func pollForReceipt(channelId string, transactionId string, client mazzaroth.Cli
 	}
 }
 
-func FromFile(path string, manifestType string) ([]*Manifest, error) {
-	manifestFile, err := ioutil.ReadFile(path)
+// FromFile reads the manifests of the given type from a file, directory or glob, see Resolve
+func FromFile(path string, manifestType string, opts ...Option) ([]*Manifest, error) {
+	resolved, err := Resolve(path, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	manifests := make([]*Manifest, 0, 0)
-	r := bytes.NewReader(manifestFile)
-
-	decoder := yaml.NewDecoder(r)
-	for {
-		manifest := &Manifest{}
-		if err := decoder.Decode(manifest); err != nil {
-			if err != io.EOF {
-				return nil, os.ErrClosed
-			}
-			break
-		}
-		if manifest.Type == manifestType {
-			manifests = append(manifests, manifest)
+	manifests := make([]*Manifest, 0, len(resolved))
+	for _, m := range resolved {
+		if m.Type == manifestType {
+			manifests = append(manifests, m)
 		}
 	}
 	return manifests, nil
 }
 
-func ExecuteDeployments(ctx context.Context, manifests []*Manifest, client mazzaroth.Client, sender string, privKey ed25519.PrivateKey) error {
-	for _, m := range manifests {
-		if m.Type != "deployment" {
-			continue
-		}
-
-		if m.Deploy == nil {
-			return errors.New("missing deploy block for manifest")
-		}
-
-		senderId, err := xdr.IDFromHexString(sender)
-		if err != nil {
-			return err
-		}
-
-		channelId, err := xdr.IDFromHexString(m.Channel.Id)
-		if err != nil {
-			return err
-		}
-
-		owner, err := xdr.IDFromHexString(m.Channel.Owner)
-		if err != nil {
-			return err
-		}
-
-		abi, err := loadAbi(m.Channel.AbiFile)
-		if err != nil {
-			return err
-		}
-
-		contract, err := loadContract(m.Channel.ContractFile)
-		if err != nil {
-			return err
-		}
-
-		tx, err := mazzaroth.Transaction(senderId, channelId).
-			Contract(mazzaroth.GenerateNonce(), maxBlockExpirationRange).Deploy(owner, m.Channel.Version, abi, contract).Sign(privKey)
-		if err != nil {
-			return err
-		}
-
-		spinnerSuccess, err := pterm.DefaultSpinner.Start("deploying contract...")
-		if err != nil {
-			return err
-		}
-
-		id, _, err := client.TransactionSubmit(ctx, tx)
-		if err != nil {
-			spinnerSuccess.Fail()
-			return err
-		}
-		spinnerSuccess.Success("contract deploy transaction submitted with tx id: ", hex.EncodeToString(id[:]))
+// Resolve reads every manifest of the files of a path, see Paths, with their includes,
+// extended manifests, defaults and environment overrides resolved and their table tests
+// expanded. Manifests are returned in the order of the files.
+func Resolve(path string, opts ...Option) ([]*Manifest, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 
-		receipt, err := pollForReceipt(m.Channel.Id, hex.EncodeToString(id[:]), client)
+	paths, err := Paths(path)
+	if err != nil {
+		return nil, err
+	}
+	documents := make([]*document, 0)
+	for _, p := range paths {
+		resolved, err := resolveDocuments(p, o, make(map[string]bool))
 		if err != nil {
-			return err
+			return nil, err
 		}
+		documents = append(documents, resolved...)
+	}
 
-		receiptJson, err := json.MarshalIndent(receipt, "", "\t")
-		if err != nil {
-			return err
+	manifests := make([]*Manifest, 0, len(documents))
+	for _, doc := range documents {
+		manifest := &Manifest{}
+		if err := doc.values.Decode(manifest); err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", doc.sources[0], err)
 		}
-		fmt.Println("contract deployment complete:receipt:\n", string(receiptJson))
-
-		for _, t := range m.Deploy.Transactions {
-			args := make([]xdr.Argument, 0, 0)
-			if len(t.Tx.Args) > 0 {
-				for _, a := range t.Tx.Args {
-					args = append(args, xdr.Argument(a))
-				}
-			}
-
-			tx, err := mazzaroth.Transaction(senderId, channelId).
-				Call(mazzaroth.GenerateNonce(), maxBlockExpirationRange).Function(t.Tx.Function).Arguments(args...).Sign(privKey)
-			if err != nil {
-				return err
-			}
-
-			id, receipt, err := client.TransactionSubmit(ctx, tx)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("transaction submitted:id:", hex.EncodeToString(id[:]))
-			if receipt == nil {
-				receipt, err = pollForReceipt(m.Channel.Id, hex.EncodeToString(id[:]), client)
-				if err != nil {
-					return err
-				}
-			}
-
-			receiptJson, err := json.MarshalIndent(receipt, "", "\t")
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("transaction complete:receipt:\n", string(receiptJson))
+		manifest.path = doc.sources[0]
+		manifest.sources = doc.sources
+		if err := manifest.expandTests(); err != nil {
+			return nil, err
 		}
+		manifests = append(manifests, manifest)
 	}
-	return nil
+	return manifests, nil
 }
 
-func ExecuteTests(ctx context.Context, manifests []*Manifest, client mazzaroth.Client, sender string, privKey ed25519.PrivateKey) error {
-	for _, m := range manifests {
-		if m.Type != "test" {
-			continue
-		}
-
-		if m.Tests == nil {
-			return errors.New("missing tests for test manifest")
-		}
-
-		senderId, err := xdr.IDFromHexString(sender)
-		if err != nil {
-			return err
-		}
-
-		channelId, err := xdr.IDFromHexString(m.Channel.Id)
-		if err != nil {
-			return err
-		}
-
-		owner, err := xdr.IDFromHexString(m.Channel.Owner)
-		if err != nil {
-			return err
-		}
-
-		for _, t := range m.Tests {
-			if t.Reset {
-				tx, err := mazzaroth.Transaction(senderId, channelId).
-					Contract(mazzaroth.GenerateNonce(), maxBlockExpirationRange).Delete().Sign(privKey)
-				if err != nil {
-					return err
-				}
-
-				id, _, err := client.TransactionSubmit(ctx, tx)
-				if err != nil {
-					return err
-				}
-
-				fmt.Println("contract delete:transaction id:", hex.EncodeToString(id[:]))
-				receipt, err := pollForReceipt(m.Channel.Id, hex.EncodeToString(id[:]), client)
-				if err != nil {
-					return err
-				}
-				receiptJson, err := json.MarshalIndent(receipt, "", "\t")
-				if err != nil {
-					return err
-				}
-				fmt.Println("contract delete complete:receipt:\n", string(receiptJson))
-			}
-			abi, err := loadAbi(m.Channel.AbiFile)
-			if err != nil {
-				return err
-			}
-
-			contract, err := loadContract(m.Channel.ContractFile)
-			if err != nil {
-				return err
-			}
-
-			tx, err := mazzaroth.Transaction(senderId, channelId).
-				Contract(mazzaroth.GenerateNonce(), maxBlockExpirationRange).Deploy(owner, m.Channel.Version, abi, contract).Sign(privKey)
-			if err != nil {
-				return err
-			}
-
-			id, _, err := client.TransactionSubmit(ctx, tx)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("contract deployed:transaction id:", hex.EncodeToString(id[:]))
-			receipt, err := pollForReceipt(m.Channel.Id, hex.EncodeToString(id[:]), client)
-			if err != nil {
-				return err
-			}
-
-			receiptJson, err := json.MarshalIndent(receipt, "", "\t")
-			if err != nil {
-				return err
-			}
-			fmt.Println("contract deployment complete:receipt:\n", string(receiptJson))
-
-			for _, t := range t.Transactions {
-				args := make([]xdr.Argument, 0, 0)
-				if len(t.Tx.Args) > 0 {
-					for _, a := range t.Tx.Args {
-						args = append(args, xdr.Argument(a))
-					}
-				}
-
-				tx, err := mazzaroth.Transaction(senderId, channelId).
-					Call(mazzaroth.GenerateNonce(), maxBlockExpirationRange).Function(t.Tx.Function).Arguments(args...).Sign(privKey)
-				if err != nil {
-					return err
-				}
-
-				id, receipt, err := client.TransactionSubmit(ctx, tx)
-				if err != nil {
-					return err
-				}
-
-				fmt.Println("transaction submitted:id:", hex.EncodeToString(id[:]))
-				if receipt == nil {
-					receipt, err = pollForReceipt(m.Channel.Id, hex.EncodeToString(id[:]), client)
-					if err != nil {
-						return err
-					}
-				}
-
-				receiptJson, err := json.MarshalIndent(receipt, "", "\t")
-				if err != nil {
-					return err
-				}
-				fmt.Println("transaction complete:receipt: \n", string(receiptJson))
-				if t.Tx.Receipt != nil {
-					if receipt.Status != xdr.Status(t.Tx.Receipt.Status) {
-						return fmt.Errorf("expected transaction status : %d does not match %d", t.Tx.Receipt.Status, receipt.Status)
-					}
-					if receipt.Result != t.Tx.Receipt.Result {
-						return fmt.Errorf("expected transaction results : %s does not match %s", t.Tx.Receipt.Result, receipt.Result)
-					}
-				}
-			}
-		}
-	}
-	return nil
+// Sources returns the manifest files the manifest was resolved from
+func (m *Manifest) Sources() []string {
+	return m.sources
 }
@@ -0,0 +1,201 @@
+package manifest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"github.com/pterm/pterm"
+)
+
+// TxMetrics are the timings and sizes of a single submitted transaction. Neither receipts nor
+// transactions carry the height of the block they were included in, so the observed height
+// is the channel height looked up once the receipt was available.
+type TxMetrics struct {
+	Label          string        `json:"label"`
+	Function       string        `json:"function,omitempty"`
+	TransactionID  string        `json:"transactionId"`
+	Status         string        `json:"status"`
+	SubmitLatency  time.Duration `json:"submitLatency"`
+	ReceiptLatency time.Duration `json:"receiptLatency"`
+	ObservedHeight uint64        `json:"observedHeight,omitempty"`
+	ReceiptSize    int           `json:"receiptSize"`
+}
+
+// Metrics aggregates the transaction metrics of a test or deployment
+type Metrics struct {
+	Transactions        []*TxMetrics  `json:"transactions"`
+	SubmitLatency       time.Duration `json:"submitLatency"`
+	ReceiptLatency      time.Duration `json:"receiptLatency"`
+	MaxReceiptLatency   time.Duration `json:"maxReceiptLatency"`
+	ReceiptSize         int           `json:"receiptSize"`
+	FirstObservedHeight uint64        `json:"firstObservedHeight,omitempty"`
+	LastObservedHeight  uint64        `json:"lastObservedHeight,omitempty"`
+}
+
+func (m *Metrics) add(t *TxMetrics) {
+	m.Transactions = append(m.Transactions, t)
+	m.SubmitLatency += t.SubmitLatency
+	m.ReceiptLatency += t.ReceiptLatency
+	if t.ReceiptLatency > m.MaxReceiptLatency {
+		m.MaxReceiptLatency = t.ReceiptLatency
+	}
+	m.ReceiptSize += t.ReceiptSize
+	if t.ObservedHeight != 0 && (m.FirstObservedHeight == 0 || t.ObservedHeight < m.FirstObservedHeight) {
+		m.FirstObservedHeight = t.ObservedHeight
+	}
+	if t.ObservedHeight > m.LastObservedHeight {
+		m.LastObservedHeight = t.ObservedHeight
+	}
+}
+
+// MeanSubmitLatency returns the average submit latency of the transactions
+func (m *Metrics) MeanSubmitLatency() time.Duration {
+	if len(m.Transactions) == 0 {
+		return 0
+	}
+	return m.SubmitLatency / time.Duration(len(m.Transactions))
+}
+
+// MeanReceiptLatency returns the average time from submitting a transaction to its receipt
+func (m *Metrics) MeanReceiptLatency() time.Duration {
+	if len(m.Transactions) == 0 {
+		return 0
+	}
+	return m.ReceiptLatency / time.Duration(len(m.Transactions))
+}
+
+// DeploymentResult is the outcome of a deployment manifest
+type DeploymentResult struct {
+	Name      string
+	ChannelID string
+	Version   string
+	Err       error
+	Metrics   *Metrics
+}
+
+// Report collects the results of the manifests executed by a runner
+type Report struct {
+	Deployments []*DeploymentResult `json:"deployments,omitempty"`
+	Tests       []*TestResult       `json:"tests,omitempty"`
+
+	// summary and path are the metrics summary and report file written by Write
+	summary bool
+	path    string
+}
+
+// NewReport returns a report for the runner when a metrics summary or a report file at path
+// was requested, and nil otherwise
+func NewReport(summary bool, path string) *Report {
+	if !summary && path == "" {
+		return nil
+	}
+	return &Report{summary: summary, path: path}
+}
+
+// Write prints the metrics summary and writes the report file requested when the report
+// was created. Nothing is written for a nil report.
+func (r *Report) Write() error {
+	if r == nil {
+		return nil
+	}
+	if r.summary {
+		if err := r.printSummary(); err != nil {
+			return err
+		}
+	}
+	if r.path != "" {
+		return r.ToFile(r.path)
+	}
+	return nil
+}
+
+// ToFile writes the report as json
+func (r *Report) ToFile(path string) error {
+	v, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, v, 0644)
+}
+
+// printSummary prints a table of the transaction metrics of each deployment and test that ran
+func (r *Report) printSummary() error {
+	data := pterm.TableData{{"name", "txs", "mean submit", "mean receipt", "max receipt", "observed heights", "receipt bytes"}}
+	row := func(name string, m *Metrics) []string {
+		if m == nil {
+			return []string{name, "0", "", "", "", "", ""}
+		}
+		round := func(d time.Duration) string {
+			return d.Round(time.Microsecond).String()
+		}
+		return []string{name,
+			fmt.Sprint(len(m.Transactions)),
+			round(m.MeanSubmitLatency()),
+			round(m.MeanReceiptLatency()),
+			round(m.MaxReceiptLatency),
+			fmt.Sprintf("%d-%d", m.FirstObservedHeight, m.LastObservedHeight),
+			fmt.Sprint(m.ReceiptSize),
+		}
+	}
+	for _, d := range r.Deployments {
+		data = append(data, row("deployment "+d.Name, d.Metrics))
+	}
+	for _, t := range r.Tests {
+		if !t.Skipped {
+			data = append(data, row("test "+t.Name, t.Metrics))
+		}
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func (t *TestResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name    string   `json:"name"`
+		Skipped bool     `json:"skipped,omitempty"`
+		Error   string   `json:"error,omitempty"`
+		Metrics *Metrics `json:"metrics,omitempty"`
+	}{t.Name, t.Skipped, errorString(t.Err), t.Metrics})
+}
+
+func (d *DeploymentResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name      string   `json:"name"`
+		ChannelID string   `json:"channelId"`
+		Version   string   `json:"version"`
+		Error     string   `json:"error,omitempty"`
+		Metrics   *Metrics `json:"metrics,omitempty"`
+	}{d.Name, d.ChannelID, d.Version, errorString(d.Err), d.Metrics})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// record adds the metrics of a transaction to the metrics collected by the runner
+func (r *Runner) record(ctx context.Context, channelId string, label string, tx *xdr.Transaction, id *xdr.ID, receipt *xdr.Receipt, start time.Time, submitted time.Time, received time.Time) {
+	t := &TxMetrics{
+		Label:          label,
+		TransactionID:  hex.EncodeToString(id[:]),
+		Status:         receipt.Status.String(),
+		SubmitLatency:  submitted.Sub(start),
+		ReceiptLatency: received.Sub(start),
+	}
+	if call, ok := tx.Data.Category.GetCall(); ok {
+		t.Function = call.Function
+	}
+	if b, err := receipt.MarshalBinary(); err == nil {
+		t.ReceiptSize = len(b)
+	}
+	if height, err := r.Client.BlockHeight(ctx, channelId); err == nil {
+		t.ObservedHeight = height.Height
+	}
+	r.metrics.add(t)
+}
@@ -0,0 +1,77 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"github.com/pterm/pterm"
+)
+
+// Output receives the progress of the manifest runners
+type Output interface {
+	// Submitted is called once a transaction has been accepted by the gateway node
+	Submitted(label string, id string)
+	// Completed is called with the receipt of a submitted transaction
+	Completed(label string, receipt *xdr.Receipt)
+	// Failed is called when a step of the runner fails
+	Failed(label string, err error)
+	// Println writes informational output
+	Println(a ...interface{})
+}
+
+var (
+	_ Output = &TerminalOutput{}
+	_ Output = &WriterOutput{}
+)
+
+// TerminalOutput writes runner progress to the terminal
+type TerminalOutput struct{}
+
+func (t *TerminalOutput) Submitted(label string, id string) {
+	pterm.Success.Println(label + " submitted:transaction id: " + id)
+}
+
+func (t *TerminalOutput) Completed(label string, receipt *xdr.Receipt) {
+	receiptJson, err := json.MarshalIndent(receipt, "", "\t")
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+	fmt.Println(label+" complete:receipt:\n", string(receiptJson))
+}
+
+func (t *TerminalOutput) Failed(label string, err error) {
+	pterm.Error.Println(label + ": " + err.Error())
+}
+
+func (t *TerminalOutput) Println(a ...interface{}) {
+	fmt.Println(a...)
+}
+
+// WriterOutput writes plain runner progress to a writer
+type WriterOutput struct {
+	W io.Writer
+}
+
+func (w *WriterOutput) Submitted(label string, id string) {
+	fmt.Fprintln(w.W, label+" submitted:transaction id:", id)
+}
+
+func (w *WriterOutput) Completed(label string, receipt *xdr.Receipt) {
+	receiptJson, err := json.Marshal(receipt)
+	if err != nil {
+		fmt.Fprintln(w.W, err)
+		return
+	}
+	fmt.Fprintln(w.W, label+" complete:receipt:", string(receiptJson))
+}
+
+func (w *WriterOutput) Failed(label string, err error) {
+	fmt.Fprintln(w.W, label+" failed:", err)
+}
+
+func (w *WriterOutput) Println(a ...interface{}) {
+	fmt.Fprintln(w.W, a...)
+}
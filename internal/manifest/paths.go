@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Stdin is the manifest path that reads manifests from standard input
+const Stdin = "-"
+
+// extensions are the file extensions of manifests read from a directory
+var extensions = map[string]bool{".yaml": true, ".yml": true, ".json": true, ".toml": true}
+
+// Paths returns the manifest files of a path, which is either a file, a directory whose
+// manifest files are read in name order, a glob pattern whose matches are read in name
+// order, or - to read standard input. An error wrapping os.ErrNotExist is returned when
+// the path matches no files.
+func Paths(path string) ([]string, error) {
+	if path == Stdin {
+		return []string{Stdin}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return []string{path}, nil
+	}
+	if err == nil {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		paths := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if !e.IsDir() && extensions[strings.ToLower(filepath.Ext(e.Name()))] {
+				paths = append(paths, filepath.Join(path, e.Name()))
+			}
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no manifest files in %s: %w", path, os.ErrNotExist)
+		}
+		return paths, nil
+	}
+
+	matches, gerr := filepath.Glob(path)
+	if gerr != nil {
+		return nil, gerr
+	}
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && !info.IsDir() {
+			paths = append(paths, m)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("manifest %s: %w", path, os.ErrNotExist)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func readManifest(path string) ([]byte, error) {
+	if path == Stdin {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,202 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	includeKey      = "include"
+	extendsKey      = "extends"
+	environmentsKey = "environments"
+	defaultsType    = "defaults"
+)
+
+// Option configures how manifest files are resolved
+type Option func(*options)
+
+type options struct {
+	environment string
+}
+
+// WithEnvironment applies the overrides of the named environment to each manifest
+func WithEnvironment(environment string) Option {
+	return func(o *options) {
+		o.environment = environment
+	}
+}
+
+// document is a manifest mapping before it is decoded, along with the files it was resolved from
+type document struct {
+	values  *yaml.Node
+	sources []string
+}
+
+// resolveDocuments reads the documents of a manifest file, resolving composition in order:
+//   - include lists files whose documents are inserted in place of the include
+//   - a document of type defaults is merged under every following document of the file
+//   - extends names a file whose first manifest is merged under the document
+//   - environments holds overrides merged over the document for the selected environment
+//
+// Paths of included and extended files are relative to the file that references them.
+func resolveDocuments(path string, o *options, stack map[string]bool) ([]*document, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if stack[abs] {
+		return nil, fmt.Errorf("manifest %s includes or extends itself", path)
+	}
+	stack[abs] = true
+	defer delete(stack, abs)
+
+	data, err := readManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	if path == Stdin {
+		dir = "."
+	}
+	var defaults *yaml.Node
+	documents := make([]*document, 0)
+	nodes, err := decodeDocuments(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	for _, values := range nodes {
+		if len(values.Content) == 0 {
+			continue
+		}
+		doc := &document{values: values, sources: []string{path}}
+
+		if include := lookup(doc.values, includeKey); include != nil {
+			paths, err := stringList(include)
+			if err != nil {
+				return nil, fmt.Errorf("manifest %s: include: %w", path, err)
+			}
+			for _, p := range paths {
+				included, err := resolveDocuments(filepath.Join(dir, p), o, stack)
+				if err != nil {
+					return nil, err
+				}
+				documents = append(documents, included...)
+			}
+			doc.values = without(doc.values, includeKey)
+			if len(doc.values.Content) == 0 {
+				continue
+			}
+		}
+
+		if t := lookup(doc.values, "type"); t != nil && t.Value == defaultsType {
+			defaults = merge(defaults, without(doc.values, "type"))
+			continue
+		}
+
+		if extends := lookup(doc.values, extendsKey); extends != nil {
+			if extends.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("manifest %s: extends must be a file path", path)
+			}
+			extended, err := resolveDocuments(filepath.Join(dir, extends.Value), o, stack)
+			if err != nil {
+				return nil, err
+			}
+			if len(extended) == 0 {
+				return nil, fmt.Errorf("manifest %s: extended manifest %s is empty", path, extends.Value)
+			}
+			doc.values = merge(extended[0].values, without(doc.values, extendsKey))
+			doc.sources = append(doc.sources, extended[0].sources...)
+		}
+
+		doc.values = merge(defaults, doc.values)
+
+		if environments := lookup(doc.values, environmentsKey); environments != nil {
+			doc.values = without(doc.values, environmentsKey)
+			if override := lookup(environments, o.environment); o.environment != "" && override != nil {
+				if override.Kind != yaml.MappingNode {
+					return nil, fmt.Errorf("manifest %s: environment %s must be a mapping", path, o.environment)
+				}
+				doc.values = merge(doc.values, override)
+			}
+		}
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+// merge returns a mapping of the values of base overridden by the values of override.
+// Mappings are merged recursively while any other value, including lists, replaces the
+// base value.
+func merge(base *yaml.Node, override *yaml.Node) *yaml.Node {
+	if base == nil {
+		return override
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	merged.Content = append(merged.Content, base.Content...)
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value != key.Value {
+				continue
+			}
+			if merged.Content[j+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+				value = merge(merged.Content[j+1], value)
+			}
+			merged.Content[j+1] = value
+			replaced = true
+			break
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
+
+// lookup returns the value of a key of a mapping, or nil when the node is not a mapping
+// or does not contain the key
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// without returns a copy of a mapping without the key
+func without(mapping *yaml.Node, key string) *yaml.Node {
+	filtered := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			filtered.Content = append(filtered.Content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	return filtered
+}
+
+func stringList(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: expected a file path", item.Line)
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("line %d: expected a file path or list of file paths", node.Line)
+	}
+}
@@ -0,0 +1,332 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes the files, keyed by their path relative to a temporary directory,
+// and returns the directory
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// describe summarizes a manifest as its type, channel id, channel version and test names
+func describe(m *Manifest) string {
+	names := make([]string, 0, len(m.Tests))
+	for _, t := range m.Tests {
+		names = append(names, t.Name)
+	}
+	return strings.Join([]string{m.Type, m.Channel.Id, m.Channel.Version, strings.Join(names, ",")}, " ")
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		path        string
+		environment string
+		expected    []string
+		expectedErr string
+	}{
+		{
+			name: "include in order",
+			files: map[string]string{
+				"main.yaml": "include: [b.yaml, sub/a.yaml]\ntype: test\nchannel: {id: main}\n",
+				"b.yaml":    "type: deployment\nchannel: {id: b}\n",
+				// includes are relative to the including file
+				"sub/a.yaml": "include: c.yaml\n",
+				"sub/c.yaml": "type: test\nchannel: {id: c}\n",
+			},
+			path:     "main.yaml",
+			expected: []string{"deployment b  ", "test c  ", "test main  "},
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"a.yaml": "include: b.yaml\n",
+				"b.yaml": "include: a.yaml\n",
+			},
+			path:        "a.yaml",
+			expectedErr: "includes or extends itself",
+		},
+		{
+			name:        "extends itself",
+			files:       map[string]string{"a.yaml": "extends: a.yaml\ntype: test\n"},
+			path:        "a.yaml",
+			expectedErr: "includes or extends itself",
+		},
+		{
+			name:        "missing include",
+			files:       map[string]string{"a.yaml": "include: missing.yaml\n"},
+			path:        "a.yaml",
+			expectedErr: "no such file or directory",
+		},
+		{
+			name: "extends overrides mappings and replaces lists",
+			files: map[string]string{
+				"base.yaml": "type: test\nchannel: {id: base, version: \"1\"}\ntests: [{name: base-test}]\n",
+				"main.yaml": "extends: base.yaml\nchannel: {version: \"2\"}\ntests: [{name: main-test}]\n",
+			},
+			path:     "main.yaml",
+			expected: []string{"test base 2 main-test"},
+		},
+		{
+			name: "extends empty manifest",
+			files: map[string]string{
+				"base.yaml": "",
+				"main.yaml": "extends: base.yaml\ntype: test\n",
+			},
+			path:        "main.yaml",
+			expectedErr: "extended manifest base.yaml is empty",
+		},
+		{
+			name:        "extends list",
+			files:       map[string]string{"main.yaml": "extends: [a.yaml]\ntype: test\n"},
+			path:        "main.yaml",
+			expectedErr: "extends must be a file path",
+		},
+		{
+			name: "defaults merged under following documents",
+			files: map[string]string{
+				"main.yaml": "type: test\nchannel: {id: before}\n---\ntype: defaults\nchannel: {id: shared, version: \"1\"}\n---\ntype: test\nchannel: {version: \"2\"}\n---\ntype: deployment\n",
+			},
+			path:     "main.yaml",
+			expected: []string{"test before  ", "test shared 2 ", "deployment shared 1 "},
+		},
+		{
+			name: "environment overrides defaults and document",
+			files: map[string]string{
+				"main.yaml": "type: defaults\nchannel: {id: shared, version: \"1\"}\n---\ntype: test\nchannel: {version: \"2\"}\nenvironments:\n  prod:\n    channel: {id: prod}\n",
+			},
+			path:        "main.yaml",
+			environment: "prod",
+			expected:    []string{"test prod 2 "},
+		},
+		{
+			name: "document overrides environment of extended manifest",
+			files: map[string]string{
+				"base.yaml": "type: test\nchannel: {id: base, version: \"1\"}\nenvironments:\n  prod:\n    channel: {id: base-prod, version: \"3\"}\n",
+				"main.yaml": "extends: base.yaml\nchannel: {version: \"2\"}\n",
+			},
+			path:        "main.yaml",
+			environment: "prod",
+			expected:    []string{"test base-prod 2 "},
+		},
+		{
+			name:        "unknown environment",
+			files:       map[string]string{"main.yaml": "type: test\nchannel: {id: main}\nenvironments:\n  prod:\n    channel: {id: prod}\n"},
+			path:        "main.yaml",
+			environment: "staging",
+			expected:    []string{"test main  "},
+		},
+		{
+			name:        "environment not a mapping",
+			files:       map[string]string{"main.yaml": "type: test\nenvironments:\n  prod: [1]\n"},
+			path:        "main.yaml",
+			environment: "prod",
+			expectedErr: "environment prod must be a mapping",
+		},
+		{
+			name:     "json array",
+			files:    map[string]string{"main.json": `[{"type": "test", "channel": {"id": "a"}}, {"type": "deployment", "channel": {"id": "b"}}]`},
+			path:     "main.json",
+			expected: []string{"test a  ", "deployment b  "},
+		},
+		{
+			name:        "invalid json",
+			files:       map[string]string{"main.json": `{"type": "test",`},
+			path:        "main.json",
+			expectedErr: "manifest",
+		},
+		{
+			name:        "json scalar",
+			files:       map[string]string{"main.json": `["test"]`},
+			path:        "main.json",
+			expectedErr: "expected a mapping",
+		},
+		{
+			name: "toml extends yaml",
+			files: map[string]string{
+				"base.yaml": "type: test\nchannel: {id: base}\n",
+				"main.toml": "extends = \"base.yaml\"\n\n[channel]\nversion = \"2\"\n\n[[tests]]\nname = \"toml-test\"\n",
+			},
+			path:     "main.toml",
+			expected: []string{"test base 2 toml-test"},
+		},
+		{
+			name:        "invalid toml",
+			files:       map[string]string{"main.toml": "type = \n[channel\n"},
+			path:        "main.toml",
+			expectedErr: "manifest",
+		},
+		{
+			name:        "invalid field type",
+			files:       map[string]string{"main.yaml": "type: test\ntests: {name: a}\n"},
+			path:        "main.yaml",
+			expectedErr: "cannot unmarshal",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, test.files)
+			manifests, err := Resolve(filepath.Join(dir, test.path), WithEnvironment(test.environment))
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := make([]string, 0, len(manifests))
+			for _, m := range manifests {
+				actual = append(actual, describe(m))
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected manifests %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		path     string
+		data     string
+		expected string
+	}{
+		{path: "a.yaml", data: `{"type": "test"}`, expected: FormatYAML},
+		{path: "a.YML", data: "type: test", expected: FormatYAML},
+		{path: "a.json", data: "type: test", expected: FormatJSON},
+		{path: "a.toml", data: "type: test", expected: FormatTOML},
+		{path: "-", data: `{"type": "test"}`, expected: FormatJSON},
+		{path: "-", data: "# comment\n\n[channel]\nid = \"a\"\n", expected: FormatTOML},
+		{path: "-", data: "type = \"test\"\n", expected: FormatTOML},
+		{path: "-", data: "type: test\n", expected: FormatYAML},
+		{path: "manifest", data: "- type: test\n", expected: FormatYAML},
+	}
+
+	for _, test := range tests {
+		if actual := Format(test.path, []byte(test.data)); actual != test.expected {
+			t.Errorf("expected format %s of %s %q, got %s", test.expected, test.path, test.data, actual)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"m8/b.yaml":      "",
+		"m8/a.json":      "",
+		"m8/c.TOML":      "",
+		"m8/notes.txt":   "",
+		"m8/sub/d.yaml":  "",
+		"tests/z.yaml":   "",
+		"tests/a.yaml":   "",
+		"tests/m.yml":    "",
+		"empty/notes.md": "",
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+		notExist bool
+	}{
+		{name: "file", path: "m8/b.yaml", expected: []string{"m8/b.yaml"}},
+		{name: "directory in name order", path: "m8", expected: []string{"m8/a.json", "m8/b.yaml", "m8/c.TOML"}},
+		{name: "glob in name order", path: "tests/*.y*ml", expected: []string{"tests/a.yaml", "tests/m.yml", "tests/z.yaml"}},
+		{name: "glob skips directories", path: "m8/*", expected: []string{"m8/a.json", "m8/b.yaml", "m8/c.TOML", "m8/notes.txt"}},
+		{name: "missing file", path: "missing.yaml", notExist: true},
+		{name: "directory without manifests", path: "empty", notExist: true},
+		{name: "glob without matches", path: "tests/*.json", notExist: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths, err := Paths(filepath.Join(dir, test.path))
+			if test.notExist {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("expected an error wrapping os.ErrNotExist, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := make([]string, 0, len(test.expected))
+			for _, p := range test.expected {
+				expected = append(expected, filepath.Join(dir, p))
+			}
+			if !reflect.DeepEqual(paths, expected) {
+				t.Errorf("expected paths %v, got %v", expected, paths)
+			}
+		})
+	}
+
+	paths, err := Paths(Stdin)
+	if err != nil || !reflect.DeepEqual(paths, []string{Stdin}) {
+		t.Errorf("expected the stdin path, got %v, %v", paths, err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	data, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := struct {
+		Definitions map[string]struct {
+			Properties map[string]struct {
+				Ref  string   `json:"$ref"`
+				Type string   `json:"type"`
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+		} `json:"definitions"`
+	}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, ok := schema.Definitions["Manifest"]
+	if !ok {
+		t.Fatal("expected a Manifest definition")
+	}
+	for _, key := range []string{includeKey, extendsKey, environmentsKey, "channel", "tests", "before_all", "signers"} {
+		if _, ok := manifest.Properties[key]; !ok {
+			t.Errorf("expected manifest property %s", key)
+		}
+	}
+	if enum := manifest.Properties["type"].Enum; !reflect.DeepEqual(enum, []string{"deployment", "test", defaultsType}) {
+		t.Errorf("expected the manifest types, got %v", enum)
+	}
+	if ref := manifest.Properties["channel"].Ref; ref != "#/definitions/Channel" {
+		t.Errorf("expected the channel to reference its definition, got %q", ref)
+	}
+	for _, key := range []string{"tx", "pause", "wait_for_blocks", "sleep", "lookup"} {
+		if _, ok := schema.Definitions["Tx"].Properties[key]; !ok {
+			t.Errorf("expected step property %s", key)
+		}
+	}
+}
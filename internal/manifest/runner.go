@@ -0,0 +1,329 @@
+package manifest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kochavalabs/m8/internal/history"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+// Runner executes deployment and test manifests against a gateway node
+type Runner struct {
+	Client  mazzaroth.Client
+	Output  Output
+	Sender  string
+	PrivKey ed25519.PrivateKey
+	// Parallel is the number of independent tests run at once, tests run sequentially when less than 2
+	Parallel int
+	// Filter selects the tests to run, every test not marked skip is run when nil
+	Filter *Filter
+	// OnResult is called with the result of each test as it is reported
+	OnResult func(result *TestResult)
+	// Report collects the results and transaction metrics of the executed manifests when set
+	Report *Report
+	// UpdateSnapshots rewrites the snapshots of the test transactions that run instead of
+	// comparing against them, the snapshots of other tests are kept
+	UpdateSnapshots bool
+	// HistoryDir is where successful deployments are recorded, recording is skipped when empty
+	HistoryDir string
+	// Guard is called with the channel id before a test resets the contract or a step pauses
+	// or deletes it, which is not submitted when it returns an error
+	Guard func(channelId string) error
+
+	// deployed is the hash of the contract last deployed by the runner to each channel,
+	// guarded by deployedLock which is shared with the runner copies of parallel tests
+	deployed     map[string]string
+	deployedLock *sync.Mutex
+	// metrics collects the transaction metrics of the current test or deployment
+	metrics *Metrics
+	// snapshots of the current manifest, along with the name of the current test and the
+	// number of snapshot transactions it ran so far
+	snapshots     *snapshotStore
+	testName      string
+	snapshotIndex int
+	// lastTransaction is the id of the last transaction submitted, looked up by transaction
+	// lookups without an id
+	lastTransaction string
+}
+
+// NewRunner returns a runner writing its progress to the terminal
+func NewRunner(client mazzaroth.Client, sender string, privKey ed25519.PrivateKey) *Runner {
+	return &Runner{
+		Client:  client,
+		Output:  &TerminalOutput{},
+		Sender:  sender,
+		PrivKey: privKey,
+	}
+}
+
+var nonceLock sync.Mutex
+
+// GenerateNonce wraps mazzaroth.GenerateNonce which is not safe for concurrent use
+func GenerateNonce() uint64 {
+	nonceLock.Lock()
+	defer nonceLock.Unlock()
+	return mazzaroth.GenerateNonce()
+}
+
+// withOutput returns a copy of the runner writing its progress to out, which collects
+// its own transaction metrics when the runner has a report.
+func (r *Runner) withOutput(out Output) *Runner {
+	c := *r
+	c.Output = out
+	c.snapshotIndex = 0
+	c.metrics = nil
+	if r.Report != nil {
+		c.metrics = &Metrics{}
+	}
+	return &c
+}
+
+// guard checks with the runner guard that the contract of the channel may be modified
+func (r *Runner) guard(channelId string) error {
+	if r.Guard == nil {
+		return nil
+	}
+	return r.Guard(channelId)
+}
+
+// submit sends a transaction and waits for its receipt, reporting a failure to the output
+func (r *Runner) submit(ctx context.Context, channelId string, label string, tx *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	id, receipt, err := r.send(ctx, channelId, label, tx)
+	if err != nil {
+		r.Output.Failed(label, err)
+	}
+	return id, receipt, err
+}
+
+// send sends a transaction and waits for its receipt, leaving the reporting of a failure to
+// the caller
+func (r *Runner) send(ctx context.Context, channelId string, label string, tx *xdr.Transaction) (*xdr.ID, *xdr.Receipt, error) {
+	start := time.Now()
+	id, receipt, err := r.Client.TransactionSubmit(ctx, tx)
+	submitted := time.Now()
+	if err != nil {
+		return nil, nil, err
+	}
+	r.Output.Submitted(label, hex.EncodeToString(id[:]))
+	r.lastTransaction = hex.EncodeToString(id[:])
+
+	if receipt == nil {
+		receipt, err = PollForReceipt(channelId, hex.EncodeToString(id[:]), r.Client)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	received := time.Now()
+	r.Output.Completed(label, receipt)
+
+	if r.metrics != nil {
+		r.record(ctx, channelId, label, tx, id, receipt, start, submitted, received)
+	}
+	return id, receipt, nil
+}
+
+func (r *Runner) deploy(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID) (*xdr.ID, *xdr.Receipt, error) {
+	owner, err := xdr.IDFromHexString(m.Channel.Owner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	abi, err := loadAbi(m.Channel.AbiFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contract, err := loadContract(m.Channel.ContractFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := mazzaroth.Transaction(senderId, channelId).
+		Contract(GenerateNonce(), maxBlockExpirationRange).Deploy(owner, m.Channel.Version, abi, contract).Sign(r.PrivKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, receipt, err := r.submit(ctx, m.Channel.Id, "contract deploy", tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.HistoryDir != "" && m.Type == "deployment" && receipt.Status == xdr.StatusSUCCESS {
+		deployment := &history.Deployment{
+			ChannelID:     m.Channel.Id,
+			Version:       m.Channel.Version,
+			Owner:         m.Channel.Owner,
+			TransactionID: hex.EncodeToString(id[:]),
+		}
+		if err := history.Record(r.HistoryDir, deployment, abi, contract); err != nil {
+			return nil, nil, err
+		}
+	}
+	return id, receipt, nil
+}
+
+// call signs a call transaction with the sender key or the signer override of the
+// transaction and submits it.
+func (r *Runner) call(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, t *Transaction) (*xdr.Receipt, error) {
+	senderId, privKey, err := r.signer(m, t.Signer, senderId)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]xdr.Argument, 0, 0)
+	if len(t.Args) > 0 {
+		for _, a := range t.Args {
+			args = append(args, xdr.Argument(a))
+		}
+	}
+
+	tx, err := mazzaroth.Transaction(senderId, channelId).
+		Call(GenerateNonce(), maxBlockExpirationRange).Function(t.Function).Arguments(args...).Sign(privKey)
+	if err != nil {
+		return nil, err
+	}
+
+	label := "transaction"
+	if t.ExpectError != "" {
+		label = "transaction (expected error)"
+	}
+	return r.submitExpecting(ctx, m.Channel.Id, label, tx, t.ExpectError)
+}
+
+// ExecuteDeployments deploys the contract of each deployment manifest followed by its transactions
+func (r *Runner) ExecuteDeployments(ctx context.Context, manifests []*Manifest) error {
+	for _, m := range manifests {
+		if m.Type != "deployment" {
+			continue
+		}
+
+		if m.Deploy == nil {
+			return errors.New("missing deploy block for manifest")
+		}
+
+		dr := r.withOutput(r.Output)
+		err := dr.executeDeployment(ctx, m)
+		if r.Report != nil {
+			r.Report.Deployments = append(r.Report.Deployments, &DeploymentResult{
+				Name:      m.Deploy.Name,
+				ChannelID: m.Channel.Id,
+				Version:   m.Channel.Version,
+				Err:       err,
+				Metrics:   dr.metrics,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) executeDeployment(ctx context.Context, m *Manifest) error {
+	senderId, err := xdr.IDFromHexString(r.Sender)
+	if err != nil {
+		return err
+	}
+
+	channelId, err := xdr.IDFromHexString(m.Channel.Id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := r.deploy(ctx, m, senderId, channelId); err != nil {
+		return err
+	}
+
+	// deployment transactions are submitted without comparing their receipts, the other
+	// steps run as they do in tests
+	for _, t := range m.Deploy.Transactions {
+		if t.Tx != nil {
+			if _, err := r.call(ctx, m, senderId, channelId, t.Tx); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.runStep(ctx, m, senderId, channelId, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteTests runs the tests of the test manifests and compares the receipts of the test
+// transactions against the expected receipts. The contract of a manifest is deployed once
+// and only redeployed for tests that reset the channel or when the contract changed. Each
+// test is reported as it completes and an error is returned when any of the tests failed.
+func (r *Runner) ExecuteTests(ctx context.Context, manifests []*Manifest) error {
+	// the map and its lock are shared with the runner copies of each test so they are
+	// created up front
+	if r.deployed == nil {
+		r.deployed = make(map[string]string)
+		r.deployedLock = &sync.Mutex{}
+	}
+
+	selected := make(map[*Test]bool)
+	for _, s := range SelectTests(manifests, r.Filter) {
+		selected[s.Test] = s.Selected
+	}
+
+	// snapshots are saved even when the tests fail so new snapshots are not lost
+	stores := make(map[string]*snapshotStore)
+	defer func() {
+		r.snapshots = nil
+		for _, store := range stores {
+			if err := store.save(); err != nil {
+				r.Output.Failed("snapshot", err)
+			}
+		}
+	}()
+
+	total, failed := 0, 0
+	for _, m := range manifests {
+		if m.Type != "test" {
+			continue
+		}
+
+		if m.Tests == nil {
+			return errors.New("missing tests for test manifest")
+		}
+
+		path := SnapshotPath(m.path)
+		if _, ok := stores[path]; !ok {
+			store, err := loadSnapshots(path, r.UpdateSnapshots)
+			if err != nil {
+				return err
+			}
+			stores[path] = store
+		}
+		r.snapshots = stores[path]
+
+		results, err := r.runTests(ctx, m, selected)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			total++
+			if result.Err != nil {
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, total)
+	}
+	if total == 0 {
+		r.Output.Println("no tests selected")
+	}
+	return nil
+}
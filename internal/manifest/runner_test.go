@@ -0,0 +1,615 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kochavalabs/m8/internal/cfg"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/gateway"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const testChannel = "2222222222222222222222222222222222222222222222222222222222222222"
+
+// readTestManifests writes the manifest, with the channel of the testdata contract prepended,
+// to a temporary directory and reads back the manifests of the given type
+func readTestManifests(t *testing.T, manifestType string, body string) []*Manifest {
+	abi, err := filepath.Abs("testdata/abi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := filepath.Abs("testdata/contract.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := fmt.Sprintf(`version: 0.0.1
+type: %s
+channel:
+  version: 0.0.1
+  id: %s
+  owner: 685fcb8d5add253247ff5fb8dcf2975de48b66458a76f6a0d23813d2ed8693b3
+  contract-file: %s
+  abi-file: %s
+%s`, manifestType, testChannel, contract, abi, body)
+
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := ioutil.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := Resolve(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifests
+}
+
+// newTestRunner returns a runner signing with a fixed key and writing its output to the buffer
+func newTestRunner(client mazzaroth.Client) (*Runner, *bytes.Buffer) {
+	privKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	r := NewRunner(client, hex.EncodeToString(privKey.Public().(ed25519.PublicKey)), privKey)
+	out := &bytes.Buffer{}
+	r.Output = &WriterOutput{W: out}
+	return r, out
+}
+
+func checkRun(t *testing.T, err error, out string, expectedErr string, expectedOutput []string, absentOutput ...string) {
+	t.Helper()
+	switch {
+	case expectedErr == "" && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case expectedErr != "" && err == nil:
+		t.Errorf("expected error containing %q", expectedErr)
+	case expectedErr != "" && !strings.Contains(err.Error(), expectedErr):
+		t.Errorf("expected error containing %q, got %v", expectedErr, err)
+	}
+	for _, expected := range expectedOutput {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output containing %q, got:\n%s", expected, out)
+		}
+	}
+	for _, absent := range absentOutput {
+		if strings.Contains(out, absent) {
+			t.Errorf("expected output without %q, got:\n%s", absent, out)
+		}
+	}
+}
+
+func TestExecuteDeployments(t *testing.T) {
+	tests := []struct {
+		name           string
+		manifestType   string
+		manifest       string
+		submitErr      error
+		expectedErr    string
+		submitted      int
+		expectedOutput []string
+	}{
+		{
+			name:         "deploy and transactions",
+			manifestType: "deployment",
+			manifest: `deploy:
+  name: foo
+  transactions:
+    - tx:
+        function: foo
+        args: ["1"]
+`,
+			submitted:      2,
+			expectedOutput: []string{"contract deploy submitted", "contract deploy complete", "transaction complete"},
+		},
+		{
+			name:         "receipts are not compared",
+			manifestType: "deployment",
+			manifest: `deploy:
+  name: foo
+  transactions:
+    - tx:
+        function: foo
+        args: ["1"]
+        receipt:
+          status: 1
+          result: two
+`,
+			submitted:      2,
+			expectedOutput: []string{"transaction complete"},
+		},
+		{
+			name:         "scenario steps",
+			manifestType: "deployment",
+			manifest: `deploy:
+  name: foo
+  transactions:
+    - pause: true
+    - unpause: true
+`,
+			submitted:      3,
+			expectedOutput: []string{"contract pause complete", "contract unpause complete"},
+		},
+		{
+			name:           "submit error",
+			manifestType:   "deployment",
+			manifest:       "deploy:\n  name: foo\n",
+			submitErr:      errors.New("gateway unreachable"),
+			expectedErr:    "gateway unreachable",
+			submitted:      1,
+			expectedOutput: []string{"contract deploy failed: gateway unreachable"},
+		},
+		{
+			name:         "missing deploy block",
+			manifestType: "deployment",
+			expectedErr:  "missing deploy block",
+		},
+		{
+			name:         "test manifests are skipped",
+			manifestType: "test",
+			manifest:     "tests: []\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := gateway.NewFake()
+			fake.Err = test.submitErr
+			r, out := newTestRunner(fake)
+
+			err := r.ExecuteDeployments(context.Background(), readTestManifests(t, test.manifestType, test.manifest))
+			checkRun(t, err, out.String(), test.expectedErr, test.expectedOutput)
+			if len(fake.Submitted) != test.submitted {
+				t.Errorf("expected %d submitted transactions, got %d", test.submitted, len(fake.Submitted))
+			}
+		})
+	}
+}
+
+func TestExecuteTests(t *testing.T) {
+	deployed := &gateway.Submit{Receipt: &xdr.Receipt{Status: xdr.StatusSUCCESS}}
+	tests := []struct {
+		name           string
+		manifest       string
+		submits        []*gateway.Submit
+		expectedErr    string
+		submitted      int
+		expectedOutput []string
+		absentOutput   []string
+	}{
+		{
+			name: "matching receipt",
+			manifest: `tests:
+  - name: call
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+          receipt:
+            status: 1
+            result: one
+`,
+			submits:        []*gateway.Submit{deployed, {Receipt: &xdr.Receipt{Status: xdr.StatusSUCCESS, Result: "one"}}},
+			submitted:      2,
+			expectedOutput: []string{"test call passed"},
+		},
+		{
+			name: "mismatched receipt",
+			manifest: `tests:
+  - name: call
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+          receipt:
+            status: 1
+            result: two
+`,
+			submits:        []*gateway.Submit{deployed, {Receipt: &xdr.Receipt{Status: xdr.StatusSUCCESS, Result: "one"}}},
+			expectedErr:    "1 of 1 tests failed",
+			submitted:      2,
+			expectedOutput: []string{"test call failed: expected transaction results : two does not match one"},
+		},
+		{
+			name: "expected submit error",
+			manifest: `tests:
+  - name: paused
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+          expect_error: paused
+`,
+			submits:        []*gateway.Submit{deployed, {Err: errors.New("channel is paused")}},
+			submitted:      2,
+			expectedOutput: []string{"transaction (expected error) failed as expected: channel is paused", "test paused passed"},
+			absentOutput:   []string{"transaction (expected error) failed:"},
+		},
+		{
+			name: "missing submit error",
+			manifest: `tests:
+  - name: paused
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+          expect_error: paused
+`,
+			expectedErr:    "1 of 1 tests failed",
+			submitted:      2,
+			expectedOutput: []string{`transaction (expected error) failed: expected transaction error matching "paused"`},
+		},
+		{
+			name: "unexpected submit error",
+			manifest: `tests:
+  - name: paused
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+          expect_error: expired
+`,
+			submits:        []*gateway.Submit{deployed, {Err: errors.New("channel is paused")}},
+			expectedErr:    "1 of 1 tests failed",
+			submitted:      2,
+			expectedOutput: []string{`transaction error "channel is paused" does not match "expired"`},
+		},
+		{
+			name: "failed deploy",
+			manifest: `tests:
+  - name: first
+    transactions:
+      - tx:
+          function: foo
+  - name: second
+    transactions:
+      - tx:
+          function: foo
+`,
+			submits: []*gateway.Submit{
+				{Receipt: &xdr.Receipt{Status: xdr.StatusFAILURE, StatusInfo: "invalid contract"}},
+				deployed,
+			},
+			expectedErr:    "1 of 2 tests failed",
+			submitted:      3,
+			expectedOutput: []string{"test first failed: contract deploy failed with status 2: invalid contract", "test second passed"},
+		},
+		{
+			name: "deployed once",
+			manifest: `tests:
+  - name: first
+    transactions:
+      - tx:
+          function: foo
+  - name: second
+    transactions:
+      - tx:
+          function: foo
+`,
+			submitted:      3,
+			expectedOutput: []string{"test first passed", "test second passed"},
+		},
+		{
+			name: "reset redeploys",
+			manifest: `tests:
+  - name: first
+    transactions:
+      - tx:
+          function: foo
+  - name: second
+    reset: true
+    transactions:
+      - tx:
+          function: foo
+`,
+			submitted:      5,
+			expectedOutput: []string{"contract delete complete", "test second passed"},
+		},
+		{
+			name: "skipped test",
+			manifest: `tests:
+  - name: skipped
+    skip: true
+    transactions:
+      - tx:
+          function: foo
+`,
+			expectedOutput: []string{"test skipped skipped", "no tests selected"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := gateway.NewFake()
+			fake.Submits = test.submits
+			r, out := newTestRunner(fake)
+
+			err := r.ExecuteTests(context.Background(), readTestManifests(t, "test", test.manifest))
+			checkRun(t, err, out.String(), test.expectedErr, test.expectedOutput, test.absentOutput...)
+			if len(fake.Submitted) != test.submitted {
+				t.Errorf("expected %d submitted transactions, got %d", test.submitted, len(fake.Submitted))
+			}
+		})
+	}
+}
+
+func TestExecuteTestsParallelDelete(t *testing.T) {
+	fake := gateway.NewFake()
+	r, out := newTestRunner(fake)
+	r.Parallel = 4
+
+	manifest := "tests:\n"
+	for i := 0; i < 4; i++ {
+		manifest += fmt.Sprintf("  - name: delete-%d\n    independent: true\n    transactions:\n      - delete: true\n", i)
+	}
+	manifest += "  - name: after\n    transactions:\n      - tx:\n          function: foo\n"
+
+	err := r.ExecuteTests(context.Background(), readTestManifests(t, "test", manifest))
+	checkRun(t, err, out.String(), "", []string{"test delete-3 passed", "test after passed"})
+	// the contract deleted by the independent tests is deployed again for the last test
+	if len(fake.Submitted) != 7 {
+		t.Errorf("expected 7 submitted transactions, got %d", len(fake.Submitted))
+	}
+}
+
+func TestProtectedChannel(t *testing.T) {
+	config := &cfg.Configuration{Channels: []*cfg.ChannelCfg{
+		{Channel: &cfg.Channel{ChannelID: testChannel, ChannelAlias: "prod", Protected: true}},
+	}}
+	tests := []struct {
+		name           string
+		step           string
+		force          bool
+		expectedErr    string
+		submitted      int
+		expectedOutput []string
+	}{
+		{name: "delete", step: "delete: true", expectedErr: "1 of 1 tests failed", submitted: 1, expectedOutput: []string{"channel is protected: prod"}},
+		{name: "pause", step: "pause: true", expectedErr: "1 of 1 tests failed", submitted: 1, expectedOutput: []string{"channel is protected: prod"}},
+		{name: "unpause", step: "unpause: true", submitted: 2, expectedOutput: []string{"test step passed"}},
+		{name: "forced delete", step: "delete: true", force: true, submitted: 2, expectedOutput: []string{"contract delete complete"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := gateway.NewFake()
+			r, out := newTestRunner(fake)
+			r.Guard = (&channel.Confirmer{Config: config, Force: test.force}).Guard
+
+			err := r.ExecuteTests(context.Background(), readTestManifests(t, "test", "tests:\n  - name: step\n    transactions:\n      - "+test.step+"\n"))
+			checkRun(t, err, out.String(), test.expectedErr, test.expectedOutput)
+			if len(fake.Submitted) != test.submitted {
+				t.Errorf("expected %d submitted transactions, got %d", test.submitted, len(fake.Submitted))
+			}
+		})
+	}
+}
+
+// TestLookup replays the gateway responses recorded in testdata/lookup.cassette.json, where a
+// call of foo returning one was submitted to a channel at block height 2
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name           string
+		lookup         string
+		expectedErr    string
+		expectedOutput []string
+	}{
+		{
+			name:           "last transaction",
+			lookup:         "transaction: {function: foo, status: success, result: one}",
+			expectedOutput: []string{"transaction 0200000000000000000000000000000000000000000000000000000000000000 lookup passed"},
+		},
+		{
+			name:           "transaction function",
+			lookup:         "transaction: {function: bar}",
+			expectedErr:    "1 of 1 tests failed",
+			expectedOutput: []string{"expected transaction function : bar does not match foo"},
+		},
+		{
+			name:           "transaction result",
+			lookup:         "transaction: {result: two}",
+			expectedErr:    "1 of 1 tests failed",
+			expectedOutput: []string{"expected transaction results : two does not match one"},
+		},
+		{
+			name:           "abi",
+			lookup:         `abi: {version: "1", functions: [foo]}`,
+			expectedOutput: []string{"abi lookup passed"},
+		},
+		{
+			name:           "abi function",
+			lookup:         "abi: {functions: [bar]}",
+			expectedErr:    "1 of 1 tests failed",
+			expectedOutput: []string{"expected abi function bar is missing"},
+		},
+		{
+			name:           "latest block",
+			lookup:         "block: {min_height: 2, transactions: 1}",
+			expectedOutput: []string{"block 2 lookup passed"},
+		},
+		{
+			name:           "block height",
+			lookup:         "block: {min_height: 3}",
+			expectedErr:    "1 of 1 tests failed",
+			expectedOutput: []string{"expected block height of at least 3, block is at height 2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cassette, err := gateway.CassetteFromFile("testdata/lookup.cassette.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, err := cassette.Replay()("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, out := newTestRunner(client)
+
+			manifests := readTestManifests(t, "test", `tests:
+  - name: lookup
+    transactions:
+      - tx:
+          function: foo
+          args: ["1"]
+      - lookup: {`+test.lookup+`}
+`)
+			err = r.ExecuteTests(context.Background(), manifests)
+			checkRun(t, err, out.String(), test.expectedErr, test.expectedOutput)
+		})
+	}
+}
+
+func TestUpdateSnapshots(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      string
+		update   bool
+		expected map[string]string
+	}{
+		{name: "filtered update keeps other tests", run: "^ta$", update: true, expected: map[string]string{"ta 1": "new", "tb 1": "kept"}},
+		{name: "update", update: true, expected: map[string]string{"ta 1": "new", "tb 1": "new", "tc 1": "new"}},
+		{name: "new snapshot", run: "^tc$", expected: map[string]string{"ta 1": "old", "tb 1": "kept", "tc 1": "new"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifests := readTestManifests(t, "test", `tests:
+  - name: ta
+    transactions:
+      - tx: {function: foo, snapshot: true}
+  - name: tb
+    transactions:
+      - tx: {function: foo, snapshot: true}
+  - name: tc
+    transactions:
+      - tx: {function: foo, snapshot: true}
+`)
+			path := SnapshotPath(manifests[0].path)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte("ta 1: old\ntb 1: kept\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			fake := gateway.NewFake()
+			fake.Submits = []*gateway.Submit{{Receipt: &xdr.Receipt{Status: xdr.StatusSUCCESS}}}
+			for i := 0; i < 3; i++ {
+				fake.Submits = append(fake.Submits, &gateway.Submit{Receipt: &xdr.Receipt{Status: xdr.StatusSUCCESS, Result: "new"}})
+			}
+			r, _ := newTestRunner(fake)
+			r.UpdateSnapshots = test.update
+			filter, err := NewFilter(test.run, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Filter = filter
+
+			if err := r.ExecuteTests(context.Background(), manifests); err != nil {
+				t.Fatal(err)
+			}
+			store, err := loadSnapshots(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(store.results, test.expected) {
+				t.Errorf("expected snapshots %v, got %v", test.expected, store.results)
+			}
+		})
+	}
+}
+
+func TestReportFile(t *testing.T) {
+	fake := gateway.NewFake()
+	r, _ := newTestRunner(fake)
+	path := filepath.Join(t.TempDir(), "report.json")
+	r.Report = NewReport(false, path)
+
+	err := r.ExecuteTests(context.Background(), readTestManifests(t, "test", "tests:\n  - name: call\n    transactions:\n      - tx: {function: foo}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Report.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := struct {
+		Tests []struct {
+			Name    string   `json:"name"`
+			Metrics *Metrics `json:"metrics"`
+		} `json:"tests"`
+	}{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tests) != 1 || report.Tests[0].Name != "call" || report.Tests[0].Metrics == nil || len(report.Tests[0].Metrics.Transactions) != 2 {
+		t.Errorf("expected the call test with the metrics of 2 transactions, got %s", data)
+	}
+	if NewReport(false, "") != nil {
+		t.Error("expected no report when neither a summary nor a file was requested")
+	}
+}
+
+func TestSigners(t *testing.T) {
+	signerKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	signerHex := hex.EncodeToString(signerKey)
+	os.Setenv("M8_TEST_SIGNER", signerHex)
+	defer os.Unsetenv("M8_TEST_SIGNER")
+
+	tests := []struct {
+		name        string
+		key         string
+		signer      string
+		expectedErr string
+	}{
+		{name: "inline key", key: signerHex, signer: "other"},
+		{name: "environment key", key: "${M8_TEST_SIGNER}", signer: "other"},
+		{name: "unset environment key", key: "${M8_TEST_UNSET}", signer: "other", expectedErr: "signer other: environment variable M8_TEST_UNSET is not set"},
+		{name: "unknown signer", key: signerHex, signer: "missing", expectedErr: "unknown signer missing"},
+		{name: "invalid key", key: "abcd", signer: "other", expectedErr: "signer other: invalid private key length 2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := gateway.NewFake()
+			r, out := newTestRunner(fake)
+
+			manifests := readTestManifests(t, "test", fmt.Sprintf(`signers:
+  other: %q
+tests:
+  - name: signed
+    transactions:
+      - tx:
+          function: foo
+          signer: %s
+`, test.key, test.signer))
+			err := r.ExecuteTests(context.Background(), manifests)
+			if test.expectedErr != "" {
+				checkRun(t, err, out.String(), "1 of 1 tests failed", []string{test.expectedErr})
+				return
+			}
+			checkRun(t, err, out.String(), "", nil)
+			if len(fake.Submitted) != 2 {
+				t.Fatalf("expected 2 submitted transactions, got %d", len(fake.Submitted))
+			}
+			if sender := fake.Submitted[1].Sender; !bytes.Equal(sender[:], signerKey.Public().(ed25519.PublicKey)) {
+				t.Errorf("expected the transaction to be signed by the signer, got sender %x", sender)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"text/template"
+)
+
+const (
+	DeploymentManifestFile = `deployment.yaml`
+	TestManifestFile       = `test.yaml`
+	manifestVersion        = `0.0.1`
+	contractVersion        = `0.0.1`
+)
+
+var deploymentTemplate = template.Must(template.New(DeploymentManifestFile).Parse(`version: {{ .Version }}
+type: deployment
+channel:
+  version: {{ .Channel.Version }}
+  id: {{ .Channel.Id }}
+  owner: {{ .Channel.Owner }}
+  contract-file: {{ printf "%q" .Channel.ContractFile }}
+  abi-file: {{ printf "%q" .Channel.AbiFile }}
+gateway-node:
+  address: {{ .GatewayNode.Address }}
+deploy:
+  name: {{ printf "%q" .Name }}
+`))
+
+var testTemplate = template.Must(template.New(TestManifestFile).Parse(`version: {{ .Version }}
+type: test
+channel:
+  version: {{ .Channel.Version }}
+  id: {{ .Channel.Id }}
+  owner: {{ .Channel.Owner }}
+  contract-file: {{ printf "%q" .Channel.ContractFile }}
+  abi-file: {{ printf "%q" .Channel.AbiFile }}
+gateway-node:
+  address: {{ .GatewayNode.Address }}
+tests:
+{{- range .Tests }}
+  - name: {{ printf "%q" .Name }}
+    reset: false
+    transactions:
+{{- range .Transactions }}
+      - tx:
+          function: {{ printf "%q" .Tx.Function }}
+          args: [{{ range $i, $a := .Tx.Args }}{{ if $i }}, {{ end }}{{ printf "%q" $a }}{{ end }}]
+{{- end }}
+{{- else }}
+  - name: {{ printf "%q" (printf "%s-test" .Name) }}
+    reset: false
+    transactions: []
+{{- end }}
+`))
+
+// Scaffold holds the values used to generate deployment and test manifests
+type Scaffold struct {
+	Name        string
+	Channel     Channel
+	GatewayNode GatewayNode
+	Tests       []*Test
+}
+
+// WriteScaffold generates a deployment and test manifest in dir. Existing manifests
+// are not overwritten unless overwrite is set, when either exists neither is written.
+func WriteScaffold(dir string, s *Scaffold, overwrite bool) error {
+	if s.Channel.Version == "" {
+		s.Channel.Version = contractVersion
+	}
+
+	names := []string{DeploymentManifestFile, TestManifestFile}
+	if !overwrite {
+		for _, name := range names {
+			p := path.Join(dir, name)
+			if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("manifest already exists at %s", p)
+			}
+		}
+	}
+
+	files := map[string]*template.Template{
+		DeploymentManifestFile: deploymentTemplate,
+		TestManifestFile:       testTemplate,
+	}
+	manifests := make(map[string][]byte, len(names))
+	for _, name := range names {
+		b := &bytes.Buffer{}
+		if err := files[name].Execute(b, struct {
+			*Scaffold
+			Version string
+		}{s, manifestVersion}); err != nil {
+			return err
+		}
+		manifests[name] = b.Bytes()
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := ioutil.WriteFile(path.Join(dir, name), manifests[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestsFromAbi generates a test skeleton with one test per abi function. Arguments are
+// filled with the parameter names and must be replaced with real values.
+func TestsFromAbi(abiPath string) ([]*Test, error) {
+	abi, err := loadAbi(abiPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make([]*Test, 0, len(abi.Functions))
+	for _, f := range abi.Functions {
+		args := make([]string, 0, len(f.Parameters))
+		for _, p := range f.Parameters {
+			args = append(args, fmt.Sprintf("<%s:%s>", p.ParameterName, p.ParameterType))
+		}
+		tests = append(tests, &Test{
+			Name: "test-" + f.FunctionName,
+			Transactions: []*Tx{
+				{
+					Tx: &Transaction{
+						Function: f.FunctionName,
+						Args:     args,
+					},
+				},
+			},
+		})
+	}
+	return tests, nil
+}
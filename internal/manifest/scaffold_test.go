@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteScaffold(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []string
+		overwrite    bool
+		contractFile string
+		expectedErr  string
+	}{
+		{name: "new project", contractFile: "contract.wasm"},
+		{name: "quoted paths", contractFile: `build\"contract".wasm`},
+		{name: "existing test manifest", existing: []string{TestManifestFile}, contractFile: "contract.wasm", expectedErr: "manifest already exists"},
+		{name: "overwrite", existing: []string{DeploymentManifestFile, TestManifestFile}, overwrite: true, contractFile: "contract.wasm"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range test.existing {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("existing"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := &Scaffold{
+				Name:    `my "app"`,
+				Channel: Channel{Id: testChannel, ContractFile: test.contractFile, AbiFile: `abi\contract.json`},
+			}
+			err := WriteScaffold(dir, s, test.overwrite)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				// nothing is written when a manifest exists
+				if _, err := os.Stat(filepath.Join(dir, DeploymentManifestFile)); !os.IsNotExist(err) {
+					t.Errorf("expected no deployment manifest to be written, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{DeploymentManifestFile, TestManifestFile} {
+				data, err := ioutil.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				m := &Manifest{}
+				if err := yaml.Unmarshal(data, m); err != nil {
+					t.Fatalf("invalid %s: %v\n%s", name, err, data)
+				}
+				if m.Channel.ContractFile != test.contractFile || m.Channel.AbiFile != `abi\contract.json` {
+					t.Errorf("expected %s files %q and %q, got %q and %q", name, test.contractFile, `abi\contract.json`, m.Channel.ContractFile, m.Channel.AbiFile)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package manifest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+var (
+	statusesType  = reflect.TypeOf(Statuses{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	operationType = reflect.TypeOf(Operation{})
+)
+
+// Schema returns a JSON Schema for manifest files, generated from the yaml fields of the
+// manifest types so editors can complete and validate manifests. The composition keys
+// resolved before a manifest is decoded are included along with the manifest fields.
+func Schema() ([]byte, error) {
+	definitions := make(map[string]interface{})
+	manifest := schemaFor(reflect.TypeOf(Manifest{}), definitions)
+
+	properties := definitions["Manifest"].(map[string]interface{})["properties"].(map[string]interface{})
+	properties[includeKey] = map[string]interface{}{
+		"description": "manifest files whose manifests are inserted in place of the include",
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	properties[extendsKey] = map[string]interface{}{
+		"description": "manifest file whose first manifest is merged under this manifest",
+		"type":        "string",
+	}
+	properties[environmentsKey] = map[string]interface{}{
+		"description":          "overrides merged over the manifest when the environment is selected",
+		"type":                 "object",
+		"additionalProperties": manifest,
+	}
+	properties["type"].(map[string]interface{})["enum"] = []string{"deployment", "test", defaultsType}
+
+	schema := map[string]interface{}{
+		"$schema":     schemaDraft,
+		"title":       "m8 manifest",
+		"definitions": definitions,
+		"oneOf": []interface{}{
+			manifest,
+			map[string]interface{}{"type": "array", "items": manifest},
+		},
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaFor returns the schema of a type, adding a definition for every struct type it
+// references and returning a reference to the definition
+func schemaFor(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	if t == statusesType {
+		status := map[string]interface{}{"type": []string{"integer", "string"}}
+		return map[string]interface{}{
+			"description": "receipt statuses given as numbers or names such as failure",
+			"oneOf": []interface{}{
+				status,
+				map[string]interface{}{"type": "array", "items": status},
+			},
+		}
+	}
+
+	if t == durationType {
+		return map[string]interface{}{"type": "string", "description": "duration such as 500ms or 2s"}
+	}
+	if t == operationType {
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"const": true},
+				structSchema(t, definitions),
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), definitions)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), definitions)}
+	case reflect.Struct:
+		return structSchema(t, definitions)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema adds the definition of a struct type unless it is already defined and
+// returns a reference to the definition
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	ref := map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	if _, ok := definitions[t.Name()]; ok {
+		return ref
+	}
+	properties := make(map[string]interface{})
+	definitions[t.Name()] = map[string]interface{}{"type": "object", "properties": properties}
+	addProperties(t, properties, definitions)
+	return ref
+}
+
+// addProperties adds the yaml fields of a struct to the properties of its schema, with the
+// fields of inline structs added to the properties of the struct embedding them
+func addProperties(t reflect.Type, properties map[string]interface{}, definitions map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		inline := false
+		for _, option := range tag[1:] {
+			inline = inline || option == "inline"
+		}
+		if inline {
+			addProperties(field.Type, properties, definitions)
+			continue
+		}
+
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = schemaFor(field.Type, definitions)
+	}
+}
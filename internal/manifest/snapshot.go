@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const snapshotDir = "__snapshots__"
+
+// snapshotStore holds the receipt results snapshotted by the tests of a manifest. Results are
+// keyed by the test name and the position of the snapshot transaction within the test run.
+type snapshotStore struct {
+	lock    sync.Mutex
+	path    string
+	update  bool
+	dirty   bool
+	results map[string]string
+}
+
+// SnapshotPath returns the snapshot file of a manifest, kept in a __snapshots__ directory
+// next to the manifest file. Snapshots of manifests read from standard input are kept in
+// the working directory.
+func SnapshotPath(manifestPath string) string {
+	if manifestPath == Stdin {
+		return filepath.Join(snapshotDir, "stdin.snap.yaml")
+	}
+	base := strings.TrimSuffix(filepath.Base(manifestPath), filepath.Ext(manifestPath))
+	return filepath.Join(filepath.Dir(manifestPath), snapshotDir, base+".snap.yaml")
+}
+
+// loadSnapshots reads the snapshot file at path. The existing snapshots are read even when
+// updating so the snapshots of tests that are not run are kept when the file is saved.
+func loadSnapshots(path string, update bool) (*snapshotStore, error) {
+	s := &snapshotStore{path: path, update: update, results: make(map[string]string)}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &s.results); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// match compares a result against its snapshot, recording the result when there is no
+// snapshot yet or snapshots are being updated.
+func (s *snapshotStore) match(key string, result string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	expected, ok := s.results[key]
+	if !ok || s.update {
+		s.results[key] = result
+		s.dirty = true
+		return true, nil
+	}
+	if expected != result {
+		return false, fmt.Errorf("snapshot %s does not match:\n  expected: %q\n  received: %q", key, expected, result)
+	}
+	return false, nil
+}
+
+func (s *snapshotStore) save() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(s.results)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.path, data, 0644); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,298 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const (
+	// blockPollInterval is how often the block height is polled while waiting for blocks
+	blockPollInterval = 500 * time.Millisecond
+	// blockWaitTimeout is how long to wait for each block before giving up
+	blockWaitTimeout = time.Minute
+)
+
+// Operation is a pause, unpause or delete of the channel contract. It is given as true or
+// as a mapping with the signer and expected outcome, the operation is expected to succeed
+// unless expect_status or expect_error is set.
+type Operation struct {
+	Signer       string   `yaml:"signer,omitempty"`
+	ExpectError  string   `yaml:"expect_error,omitempty"`
+	ExpectStatus Statuses `yaml:"expect_status,omitempty"`
+}
+
+func (o *Operation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	enabled := false
+	if err := unmarshal(&enabled); err == nil {
+		if !enabled {
+			return errors.New("an operation step must be true or a mapping")
+		}
+		return nil
+	}
+
+	type operation Operation
+	return unmarshal((*operation)(o))
+}
+
+// Lookup reads the abi, a block or a transaction of the channel and asserts on the result.
+// Exactly one of the fields is set.
+type Lookup struct {
+	Abi         *AbiLookup         `yaml:"abi,omitempty"`
+	Block       *BlockLookup       `yaml:"block,omitempty"`
+	Transaction *TransactionLookup `yaml:"transaction,omitempty"`
+}
+
+// AbiLookup asserts on the abi of the channel
+type AbiLookup struct {
+	Version string `yaml:"version,omitempty"`
+	// Functions that must be present in the abi
+	Functions []string `yaml:"functions,omitempty"`
+}
+
+// BlockLookup asserts on a block selected by id or height, the latest block when neither is set
+type BlockLookup struct {
+	ID     string  `yaml:"id,omitempty"`
+	Height *uint64 `yaml:"height,omitempty"`
+	// MinHeight is the lowest height the block may have
+	MinHeight uint64 `yaml:"min_height,omitempty"`
+	// Transactions is the number of transactions the block must hold
+	Transactions *int `yaml:"transactions,omitempty"`
+}
+
+// TransactionLookup asserts on a transaction and its receipt, the last transaction submitted
+// by the test or deployment when no id is set
+type TransactionLookup struct {
+	ID       string   `yaml:"id,omitempty"`
+	Function string   `yaml:"function,omitempty"`
+	Status   Statuses `yaml:"status,omitempty"`
+	Result   string   `yaml:"result,omitempty"`
+}
+
+// runStep runs a step of a deployment, test or hook according to its kind
+func (r *Runner) runStep(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, t *Tx) error {
+	switch {
+	case t.Tx != nil:
+		return r.runCall(ctx, m, senderId, channelId, t.Tx)
+	case t.Pause != nil:
+		return r.operate(ctx, m, senderId, channelId, "pause", t.Pause)
+	case t.Unpause != nil:
+		return r.operate(ctx, m, senderId, channelId, "unpause", t.Unpause)
+	case t.Delete != nil:
+		return r.operate(ctx, m, senderId, channelId, "delete", t.Delete)
+	case t.WaitForBlocks > 0:
+		return r.waitForBlocks(ctx, m.Channel.Id, t.WaitForBlocks)
+	case t.Sleep > 0:
+		r.Output.Println("sleeping " + t.Sleep.String())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.Sleep):
+			return nil
+		}
+	case t.Lookup != nil:
+		return r.lookup(ctx, m.Channel.Id, t.Lookup)
+	default:
+		return errors.New("step has none of tx, pause, unpause, delete, wait_for_blocks, sleep or lookup")
+	}
+}
+
+// operate submits a pause, unpause or delete of the channel contract, checking a pause or
+// delete with the runner guard first. A successful delete forgets the deployment of the
+// runner so the contract is deployed again for the next test.
+func (r *Runner) operate(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, kind string, op *Operation) error {
+	if kind != "unpause" {
+		if err := r.guard(m.Channel.Id); err != nil {
+			return err
+		}
+	}
+
+	senderId, privKey, err := r.signer(m, op.Signer, senderId)
+	if err != nil {
+		return err
+	}
+
+	builder := mazzaroth.Transaction(senderId, channelId).Contract(GenerateNonce(), maxBlockExpirationRange)
+	switch kind {
+	case "pause":
+		builder = builder.Pause(true)
+	case "unpause":
+		builder = builder.Pause(false)
+	case "delete":
+		builder = builder.Delete()
+	}
+	tx, err := builder.Sign(privKey)
+	if err != nil {
+		return err
+	}
+
+	label := "contract " + kind
+	if op.ExpectError != "" {
+		label += " (expected error)"
+	}
+	receipt, err := r.submitExpecting(ctx, m.Channel.Id, label, tx, op.ExpectError)
+	// an operation that failed as expected has no receipt to compare
+	if err != nil || receipt == nil {
+		return err
+	}
+
+	expected := op.ExpectStatus
+	if len(expected) == 0 {
+		expected = Statuses{int32(xdr.StatusSUCCESS)}
+	}
+	if !expected.contains(receipt.Status) {
+		return fmt.Errorf("expected %s status in %v does not match %d", label, []int32(expected), receipt.Status)
+	}
+
+	if kind == "delete" && receipt.Status == xdr.StatusSUCCESS && r.deployed != nil {
+		r.deployedLock.Lock()
+		delete(r.deployed, m.Channel.Id)
+		r.deployedLock.Unlock()
+	}
+	return nil
+}
+
+// waitForBlocks waits until the channel is the given number of blocks past its current height.
+// The devnode only produces blocks for submitted transactions, so against it the wait times
+// out unless other clients submit transactions to the channel.
+func (r *Runner) waitForBlocks(ctx context.Context, channelId string, blocks uint64) error {
+	start, err := r.Client.BlockHeight(ctx, channelId)
+	if err != nil {
+		return err
+	}
+
+	target := start.Height + blocks
+	r.Output.Println(fmt.Sprintf("waiting for block %d", target))
+	deadline := time.Now().Add(time.Duration(blocks) * blockWaitTimeout)
+	for {
+		height, err := r.Client.BlockHeight(ctx, channelId)
+		if err != nil {
+			return err
+		}
+		if height.Height >= target {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for block %d, channel is at block %d", target, height.Height)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(blockPollInterval):
+		}
+	}
+}
+
+// lookup reads the abi, block or transaction of a lookup step and compares it against the
+// expected values
+func (r *Runner) lookup(ctx context.Context, channelId string, l *Lookup) error {
+	switch {
+	case l.Abi != nil:
+		abi, err := r.Client.ChannelAbi(ctx, channelId)
+		if err != nil {
+			return err
+		}
+		if l.Abi.Version != "" && abi.Version != l.Abi.Version {
+			return fmt.Errorf("expected abi version : %s does not match %s", l.Abi.Version, abi.Version)
+		}
+		functions := make(map[string]bool, len(abi.Functions))
+		for _, f := range abi.Functions {
+			functions[f.FunctionName] = true
+		}
+		for _, f := range l.Abi.Functions {
+			if !functions[f] {
+				return fmt.Errorf("expected abi function %s is missing", f)
+			}
+		}
+		r.Output.Println("abi lookup passed")
+		return nil
+
+	case l.Block != nil:
+		block, err := r.lookupBlock(ctx, channelId, l.Block)
+		if err != nil {
+			return err
+		}
+		if block.Header.BlockHeight < l.Block.MinHeight {
+			return fmt.Errorf("expected block height of at least %d, block is at height %d", l.Block.MinHeight, block.Header.BlockHeight)
+		}
+		if l.Block.Transactions != nil && len(block.Transactions) != *l.Block.Transactions {
+			return fmt.Errorf("expected block transactions : %d does not match %d", *l.Block.Transactions, len(block.Transactions))
+		}
+		r.Output.Println("block " + strconv.FormatUint(block.Header.BlockHeight, 10) + " lookup passed")
+		return nil
+
+	case l.Transaction != nil:
+		id := l.Transaction.ID
+		if id == "" {
+			id = r.lastTransaction
+		}
+		if id == "" {
+			return errors.New("transaction lookup without an id before any transaction was submitted")
+		}
+
+		if l.Transaction.Function != "" {
+			tx, err := r.Client.TransactionLookup(ctx, channelId, id)
+			if err != nil {
+				return err
+			}
+			call, ok := tx.Data.Category.GetCall()
+			if !ok {
+				return fmt.Errorf("expected transaction %s to call %s, it is not a call", id, l.Transaction.Function)
+			}
+			if call.Function != l.Transaction.Function {
+				return fmt.Errorf("expected transaction function : %s does not match %s", l.Transaction.Function, call.Function)
+			}
+		}
+
+		if len(l.Transaction.Status) > 0 || l.Transaction.Result != "" {
+			receipt, err := PollForReceipt(channelId, id, r.Client)
+			if err != nil {
+				return err
+			}
+			if len(l.Transaction.Status) > 0 && !l.Transaction.Status.contains(receipt.Status) {
+				return fmt.Errorf("expected transaction status in %v does not match %d", []int32(l.Transaction.Status), receipt.Status)
+			}
+			if l.Transaction.Result != "" && receipt.Result != l.Transaction.Result {
+				return fmt.Errorf("expected transaction results : %s does not match %s", l.Transaction.Result, receipt.Result)
+			}
+		}
+		r.Output.Println("transaction " + id + " lookup passed")
+		return nil
+
+	default:
+		return errors.New("lookup has none of abi, block or transaction")
+	}
+}
+
+// lookupBlock returns the block of a block lookup by id, by height or the latest block
+func (r *Runner) lookupBlock(ctx context.Context, channelId string, l *BlockLookup) (*xdr.Block, error) {
+	if l.ID != "" {
+		return r.Client.BlockLookup(ctx, channelId, l.ID)
+	}
+
+	height := uint64(0)
+	if l.Height != nil {
+		height = *l.Height
+	} else {
+		h, err := r.Client.BlockHeight(ctx, channelId)
+		if err != nil {
+			return nil, err
+		}
+		height = h.Height
+	}
+
+	blocks, err := r.Client.BlockList(ctx, channelId, int(height), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	return &blocks[0], nil
+}
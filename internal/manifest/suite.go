@@ -0,0 +1,316 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+// TestResult is the outcome of a single test of a test manifest
+type TestResult struct {
+	Name    string
+	Skipped bool
+	Err     error
+	// Log is the buffered runner output of tests run in parallel
+	Log string
+	// Metrics are the transaction metrics of the test, collected when the runner has a report
+	Metrics *Metrics
+}
+
+// runTests runs the selected tests of a manifest in order and reports the others as skipped.
+// The contract is only redeployed for tests that reset the channel or when it changed.
+// Consecutive independent tests are run concurrently, their output is buffered and
+// reported in manifest order once the batch completes.
+func (r *Runner) runTests(ctx context.Context, m *Manifest, selected map[*Test]bool) ([]*TestResult, error) {
+	senderId, err := xdr.IDFromHexString(r.Sender)
+	if err != nil {
+		return nil, err
+	}
+
+	channelId, err := xdr.IDFromHexString(m.Channel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make([]*Test, 0, len(m.Tests))
+	for _, t := range m.Tests {
+		if t.Independent && t.Reset {
+			return nil, fmt.Errorf("test %s: independent tests can not reset the channel", t.Name)
+		}
+		for _, name := range t.Fixtures {
+			if _, ok := m.Fixtures[name]; !ok {
+				return nil, fmt.Errorf("test %s: unknown fixture %s", t.Name, name)
+			}
+		}
+		if !selected[t] {
+			r.report(&TestResult{Name: t.Name, Skipped: true})
+			continue
+		}
+		tests = append(tests, t)
+	}
+
+	if len(tests) == 0 {
+		return nil, nil
+	}
+
+	if len(m.BeforeAll) > 0 {
+		if err := r.ensureDeployed(ctx, m, senderId, channelId, false); err != nil {
+			return nil, err
+		}
+		if err := r.runHook(ctx, m, senderId, channelId, "before_all", m.BeforeAll); err != nil {
+			return nil, fmt.Errorf("before_all: %w", err)
+		}
+	}
+
+	results := make([]*TestResult, len(tests))
+	for i := 0; i < len(tests); {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		t := tests[i]
+		if !t.Independent || r.Parallel < 2 {
+			tr := r.withOutput(r.Output)
+			err := tr.runTest(ctx, m, senderId, channelId, t, true)
+			results[i] = &TestResult{Name: t.Name, Err: err, Metrics: tr.metrics}
+			r.report(results[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(tests) && tests[j].Independent {
+			j++
+		}
+
+		if err := r.ensureDeployed(ctx, m, senderId, channelId, false); err != nil {
+			return nil, err
+		}
+		r.runParallel(ctx, m, senderId, channelId, tests[i:j], results[i:j])
+		for _, result := range results[i:j] {
+			r.report(result)
+		}
+		i = j
+	}
+
+	if err := r.runHook(ctx, m, senderId, channelId, "after_all", m.AfterAll); err != nil {
+		return nil, fmt.Errorf("after_all: %w", err)
+	}
+	return results, nil
+}
+
+// ensureDeployed deploys the contract of a manifest unless force is not set and the runner
+// already deployed the same contract, abi and version to the channel. A deploy receipt
+// without a success status is returned as an error.
+func (r *Runner) ensureDeployed(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, force bool) error {
+	hash, err := deploymentHash(m)
+	if err != nil {
+		return err
+	}
+
+	r.deployedLock.Lock()
+	deployed := r.deployed[m.Channel.Id] == hash
+	r.deployedLock.Unlock()
+	if !force && deployed {
+		return nil
+	}
+
+	_, receipt, err := r.deploy(ctx, m, senderId, channelId)
+	if err != nil {
+		return err
+	}
+
+	// a failed deployment fails the test and is retried by the next test
+	r.deployedLock.Lock()
+	defer r.deployedLock.Unlock()
+	if receipt.Status != xdr.StatusSUCCESS {
+		delete(r.deployed, m.Channel.Id)
+		return fmt.Errorf("contract deploy failed with status %d: %s", receipt.Status, receipt.StatusInfo)
+	}
+	r.deployed[m.Channel.Id] = hash
+	return nil
+}
+
+// deploymentHash returns the hash of the contract, abi and version deployed for a manifest
+func deploymentHash(m *Manifest) (string, error) {
+	abi, err := ioutil.ReadFile(m.Channel.AbiFile)
+	if err != nil {
+		return "", err
+	}
+
+	contract, err := ioutil.ReadFile(m.Channel.ContractFile)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := &crypto.Sha3_256Hasher{}
+	data := append(append(append([]byte(m.Channel.Version), 0), abi...), contract...)
+	return crypto.ToHex(hasher.Hash(data)), nil
+}
+
+// runParallel runs tests on a bounded pool of workers, storing each result at the index of its test
+func (r *Runner) runParallel(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, tests []*Test, results []*TestResult) {
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < r.Parallel && w < len(tests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				buf := &bytes.Buffer{}
+				tr := r.withOutput(&WriterOutput{W: buf})
+				err := tr.runTest(ctx, m, senderId, channelId, tests[i], false)
+				results[i] = &TestResult{Name: tests[i].Name, Err: err, Log: buf.String(), Metrics: tr.metrics}
+			}
+		}()
+	}
+
+	for i := range tests {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// runTest executes the hooks, fixtures and transactions of a single test. When deploy is set
+// the channel is reset first if the test requests it, and the contract is deployed if it
+// changed since the last deployment of the runner.
+func (r *Runner) runTest(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, t *Test, deploy bool) error {
+	r.testName = t.Name
+	if deploy {
+		if t.Reset {
+			if err := r.guard(m.Channel.Id); err != nil {
+				return err
+			}
+			tx, err := mazzaroth.Transaction(senderId, channelId).
+				Contract(GenerateNonce(), maxBlockExpirationRange).Delete().Sign(r.PrivKey)
+			if err != nil {
+				return err
+			}
+
+			if _, _, err := r.submit(ctx, m.Channel.Id, "contract delete", tx); err != nil {
+				return err
+			}
+		}
+
+		if err := r.ensureDeployed(ctx, m, senderId, channelId, t.Reset); err != nil {
+			return err
+		}
+	}
+
+	err := r.runTxs(ctx, m, senderId, channelId, m.BeforeEach)
+	for _, name := range t.Fixtures {
+		if err != nil {
+			break
+		}
+		err = r.runTxs(ctx, m, senderId, channelId, m.Fixtures[name])
+	}
+	if err == nil {
+		err = r.runTxs(ctx, m, senderId, channelId, t.BeforeAll)
+	}
+	for _, tx := range t.Transactions {
+		if err != nil {
+			break
+		}
+		if err = r.runTxs(ctx, m, senderId, channelId, t.BeforeEach); err != nil {
+			break
+		}
+		err = r.runTxs(ctx, m, senderId, channelId, []*Tx{tx})
+		// after hooks run even when the transaction failed, the first error is returned
+		if aerr := r.runTxs(ctx, m, senderId, channelId, t.AfterEach); err == nil {
+			err = aerr
+		}
+	}
+	if aerr := r.runTxs(ctx, m, senderId, channelId, t.AfterAll); err == nil {
+		err = aerr
+	}
+	if aerr := r.runTxs(ctx, m, senderId, channelId, m.AfterEach); err == nil {
+		err = aerr
+	}
+	return err
+}
+
+// runHook runs the transactions of a manifest level hook, snapshotted under the hook name
+func (r *Runner) runHook(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, name string, txs []*Tx) error {
+	hr := r.withOutput(r.Output)
+	hr.testName = name
+	return hr.runTxs(ctx, m, senderId, channelId, txs)
+}
+
+// runTxs runs steps in order, stopping at the first step that fails
+func (r *Runner) runTxs(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, txs []*Tx) error {
+	for _, t := range txs {
+		if err := r.runStep(ctx, m, senderId, channelId, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCall submits a call transaction, comparing its receipt against the expected receipt
+// and the result of a snapshot transaction against its snapshot.
+func (r *Runner) runCall(ctx context.Context, m *Manifest, senderId xdr.ID, channelId xdr.ID, t *Transaction) error {
+	receipt, err := r.call(ctx, m, senderId, channelId, t)
+	if err != nil {
+		return err
+	}
+	// a transaction that failed as expected has no receipt to compare
+	if receipt == nil {
+		return nil
+	}
+
+	if len(t.ExpectStatus) > 0 && !t.ExpectStatus.contains(receipt.Status) {
+		return fmt.Errorf("expected transaction status in %v does not match %d", []int32(t.ExpectStatus), receipt.Status)
+	}
+
+	if t.Receipt != nil {
+		if receipt.Status != xdr.Status(t.Receipt.Status) {
+			return fmt.Errorf("expected transaction status : %d does not match %d", t.Receipt.Status, receipt.Status)
+		}
+		if receipt.Result != t.Receipt.Result {
+			return fmt.Errorf("expected transaction results : %s does not match %s", t.Receipt.Result, receipt.Result)
+		}
+	}
+
+	if t.Snapshot && r.snapshots != nil {
+		r.snapshotIndex++
+		key := fmt.Sprintf("%s %d", r.testName, r.snapshotIndex)
+		written, err := r.snapshots.match(key, receipt.Result)
+		if err != nil {
+			return err
+		}
+		if written {
+			r.Output.Println("snapshot " + key + " written")
+		}
+	}
+	return nil
+}
+
+// report writes the buffered output and outcome of a test
+func (r *Runner) report(result *TestResult) {
+	if r.OnResult != nil {
+		r.OnResult(result)
+	}
+	if r.Report != nil {
+		r.Report.Tests = append(r.Report.Tests, result)
+	}
+	if result.Skipped {
+		r.Output.Println("test " + result.Name + " skipped")
+		return
+	}
+	if result.Log != "" {
+		r.Output.Println(strings.TrimRight(result.Log, "\n"))
+	}
+	if result.Err != nil {
+		r.Output.Failed("test "+result.Name, result.Err)
+		return
+	}
+	r.Output.Println("test " + result.Name + " passed")
+}
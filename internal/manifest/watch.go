@@ -0,0 +1,119 @@
+package manifest
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchPaths returns the manifest files of the path along with the files the manifests were
+// resolved from and their contract and abi files, sorted and without duplicates.
+func WatchPaths(manifestPath string, manifests []*Manifest) []string {
+	unique := make(map[string]bool)
+	paths, _ := Paths(manifestPath)
+	for _, p := range paths {
+		unique[filepath.Clean(p)] = true
+	}
+	for _, m := range manifests {
+		for _, source := range m.sources {
+			unique[filepath.Clean(source)] = true
+		}
+		unique[filepath.Clean(m.Channel.ContractFile)] = true
+		unique[filepath.Clean(m.Channel.AbiFile)] = true
+	}
+
+	paths = make([]string, 0, len(unique))
+	for p := range unique {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Affected returns the manifests with a contract or abi file in the changed paths
+func Affected(manifests []*Manifest, changed []string) []*Manifest {
+	affected := make([]*Manifest, 0)
+	for _, m := range manifests {
+		for _, p := range changed {
+			if p == filepath.Clean(m.Channel.ContractFile) || p == filepath.Clean(m.Channel.AbiFile) {
+				affected = append(affected, m)
+				break
+			}
+		}
+	}
+	return affected
+}
+
+// Watch sends the sorted paths that changed each time one of the paths is written, created
+// or replaced. The directories of the paths are watched so that files replaced by a build
+// are still picked up, and events are collected until no change was seen for the debounce
+// duration. The returned channel is closed when the context is done.
+func Watch(ctx context.Context, paths []string, debounce time.Duration) (<-chan []string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		watched[p] = true
+		dir := filepath.Dir(p)
+		if dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		dirs[dir] = true
+	}
+
+	changes := make(chan []string)
+	go func() {
+		defer close(changes)
+		defer watcher.Close()
+
+		changed := make(map[string]bool)
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				p := filepath.Clean(event.Name)
+				if !watched[p] || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				changed[p] = true
+				timer.Reset(debounce)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-timer.C:
+				batch := make([]string, 0, len(changed))
+				for p := range changed {
+					batch = append(batch, p)
+				}
+				sort.Strings(batch)
+				changed = make(map[string]bool)
+
+				select {
+				case changes <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/charmbracelet/bubbles/stopwatch"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kochavalabs/m8/internal/channel"
+	"github.com/kochavalabs/m8/internal/manifest"
 	"github.com/kochavalabs/mazzaroth-go"
 	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
 )
@@ -21,6 +24,7 @@ type ChannelModel struct {
 	cmd       tea.Cmd
 	id        *xdr.ID
 	abi       *xdr.Abi
+	status    *channel.Status
 	err       error
 
 	quit bool
@@ -56,6 +60,10 @@ func (c ChannelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		c.abi = msg
 		c.quit = true
 		return c, tea.Quit
+	case *channel.Status:
+		c.status = msg
+		c.quit = true
+		return c, tea.Quit
 	case error:
 		c.err = error(msg)
 		c.quit = true
@@ -103,6 +111,13 @@ func (c ChannelModel) View() string {
 		} else {
 			output = string(v)
 		}
+	} else if c.status != nil {
+		v, err := json.MarshalIndent(c.status, "", " ")
+		if err != nil {
+			c.err = err
+		} else {
+			output = string(v)
+		}
 	} else if c.err != nil {
 		errText := lipgloss.NewStyle().
 			Bold(true).
@@ -154,3 +169,45 @@ func ChannelAbiLookup(ctx context.Context, client mazzaroth.Client, channelId st
 		return abi
 	}
 }
+
+// ChannelPauseStatus submits a pause transaction, waits for its receipt and returns the
+// resulting channel status.
+func ChannelPauseStatus(ctx context.Context, client mazzaroth.Client, tx *xdr.Transaction, depth int) ChannelCmd {
+	return func() tea.Msg {
+		if tx.Data.Category.Type != xdr.CategoryTypePAUSE {
+			return errors.New("invalid transaction type supplied to pause cmd")
+		}
+
+		channelId := hex.EncodeToString(tx.Data.ChannelID[:])
+		id, receipt, err := client.TransactionSubmit(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if receipt == nil {
+			receipt, err = manifest.PollForReceipt(channelId, hex.EncodeToString(id[:]), client)
+			if err != nil {
+				return err
+			}
+		}
+		if receipt.Status != xdr.StatusSUCCESS {
+			return fmt.Errorf("pause transaction %s failed with status %s: %s",
+				hex.EncodeToString(id[:]), receipt.Status, receipt.StatusInfo)
+		}
+
+		status, err := channel.LookupStatus(ctx, client, channelId, depth)
+		if err != nil {
+			return err
+		}
+		return status
+	}
+}
+
+func ChannelStatusLookup(ctx context.Context, client mazzaroth.Client, channelId string, depth int) ChannelCmd {
+	return func() tea.Msg {
+		status, err := channel.LookupStatus(ctx, client, channelId, depth)
+		if err != nil {
+			return err
+		}
+		return status
+	}
+}
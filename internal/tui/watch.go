@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kochavalabs/m8/internal/manifest"
+)
+
+var _ tea.Model = &WatchModel{}
+
+// WatchRunMsg starts a test run triggered by the changed paths, the first run has no changes
+type WatchRunMsg struct {
+	Changed []string
+}
+
+// WatchDoneMsg completes a test run
+type WatchDoneMsg struct {
+	Err error
+}
+
+// WatchModel shows the results of test runs started whenever the watched files change.
+// Test runs are reported through WatchRunMsg, *manifest.TestResult and WatchDoneMsg
+// messages sent on the events channel.
+type WatchModel struct {
+	events  <-chan tea.Msg
+	paths   []string
+	changed []string
+	runs    int
+	running bool
+	started time.Time
+	elapsed time.Duration
+	results []*manifest.TestResult
+	err     error
+}
+
+func NewWatchModel(paths []string, events <-chan tea.Msg) *WatchModel {
+	return &WatchModel{
+		events: events,
+		paths:  paths,
+	}
+}
+
+// nextEvent waits for the next test run event
+func (w WatchModel) nextEvent() tea.Msg {
+	msg, ok := <-w.events
+	if !ok {
+		return tea.Quit()
+	}
+	return msg
+}
+
+func (w WatchModel) Init() tea.Cmd {
+	return w.nextEvent
+}
+
+func (w WatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return w, tea.Quit
+		default:
+			return w, nil
+		}
+	case WatchRunMsg:
+		w.runs++
+		w.running = true
+		w.started = time.Now()
+		w.changed = msg.Changed
+		w.results = nil
+		w.err = nil
+		return w, w.nextEvent
+	case *manifest.TestResult:
+		w.results = append(w.results, msg)
+		return w, w.nextEvent
+	case WatchDoneMsg:
+		w.running = false
+		w.elapsed = time.Since(w.started)
+		w.err = msg.Err
+		return w, w.nextEvent
+	default:
+		return w, nil
+	}
+}
+
+func (w WatchModel) View() string {
+	m8Text := barStyle.Copy().
+		Foreground(lipgloss.Color(darkGrey)).
+		Background(lipgloss.Color(gold)).MarginLeft(1).Render("m8")
+	modeText := barStyle.Copy().
+		Background(lipgloss.Color(teal)).Render("watch")
+	titleText := barStyle.Copy().
+		Width(101 - lipgloss.Width(m8Text) - lipgloss.Width(modeText)).
+		Render("tests")
+	barText := lipgloss.JoinHorizontal(lipgloss.Top, m8Text, titleText, modeText)
+
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(teal))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(red))
+	dimStyle := lipgloss.NewStyle().Faint(true)
+
+	lines := []string{dimStyle.Render("watching " + strings.Join(w.paths, ", "))}
+	if len(w.changed) > 0 {
+		lines = append(lines, dimStyle.Render("changed "+strings.Join(w.changed, ", ")))
+	}
+	lines = append(lines, "")
+
+	passed, failed := 0, 0
+	for _, r := range w.results {
+		switch {
+		case r.Skipped:
+			lines = append(lines, dimStyle.Render("- "+r.Name+" skipped"))
+		case r.Err != nil:
+			failed++
+			lines = append(lines, failStyle.Render("✗ "+r.Name+": "+r.Err.Error()))
+		default:
+			passed++
+			lines = append(lines, passStyle.Render("✓ "+r.Name))
+		}
+	}
+
+	status := ""
+	switch {
+	case w.runs == 0:
+		status = "waiting for first run..."
+	case w.running:
+		status = fmt.Sprintf("run %d: running...", w.runs)
+	case w.err != nil && passed+failed == 0:
+		status = failStyle.Render(fmt.Sprintf("run %d: error: %s", w.runs, w.err))
+	default:
+		status = fmt.Sprintf("run %d: %d passed, %d failed in %s", w.runs, passed, failed, w.elapsed.Round(time.Millisecond))
+		if failed > 0 {
+			status = failStyle.Render(status)
+		} else {
+			status = passStyle.Render(status)
+		}
+	}
+	lines = append(lines, "", status, dimStyle.Render("press q to quit"))
+	return lipgloss.JoinVertical(lipgloss.Top, barText, strings.Join(lines, "\n")) + "\n"
+}
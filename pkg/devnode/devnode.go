@@ -0,0 +1,408 @@
+// Package devnode provides an in-memory mazzaroth gateway node that serves the gateway
+// http api, so that m8 and channel manifests can be exercised without a running node.
+package devnode
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kochavalabs/crypto"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrNotFound is returned when a channel entity does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidSignature is returned when a transaction signature does not match its sender
+	ErrInvalidSignature = errors.New("invalid transaction signature")
+	// ErrExpired is returned when a transaction block expiration number is below the channel height
+	ErrExpired = errors.New("transaction expired")
+)
+
+// Response is a scripted receipt returned for call transactions matching the function
+// and, if set, the arguments. A non empty Reject causes the submit itself to fail.
+type Response struct {
+	ChannelID  string   `yaml:"channel-id,omitempty"`
+	Function   string   `yaml:"function"`
+	Args       []string `yaml:"args,omitempty"`
+	Status     int32    `yaml:"status,omitempty"`
+	Result     string   `yaml:"result,omitempty"`
+	StatusInfo string   `yaml:"status-info,omitempty"`
+	Reject     string   `yaml:"reject,omitempty"`
+}
+
+// Script is a list of scripted responses, the first matching response is used
+type Script struct {
+	Responses []*Response `yaml:"responses"`
+}
+
+// ScriptFromFile loads a yaml script of responses
+func ScriptFromFile(path string) (*Script, error) {
+	scriptFile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	script := &Script{}
+	if err := yaml.Unmarshal(scriptFile, script); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// Option configures a Node
+type Option func(*Node)
+
+// WithScript adds the scripted responses to the node
+func WithScript(script *Script) Option {
+	return func(n *Node) {
+		n.responses = append(n.responses, script.Responses...)
+	}
+}
+
+// WithBlockInterval batches submitted transactions into a block every interval,
+// by default a block is produced for every transaction.
+func WithBlockInterval(interval time.Duration) Option {
+	return func(n *Node) {
+		n.blockInterval = interval
+	}
+}
+
+// WithExpirationCheck rejects transactions with a block expiration number below the channel height
+func WithExpirationCheck(check bool) Option {
+	return func(n *Node) {
+		n.checkExpiration = check
+	}
+}
+
+// Node is an in-memory mazzaroth node holding any number of channels
+type Node struct {
+	mu              sync.Mutex
+	channels        map[string]*channel
+	responses       []*Response
+	blockInterval   time.Duration
+	checkExpiration bool
+}
+
+type contract struct {
+	owner   xdr.ID
+	version string
+	abi     xdr.Abi
+	hash    xdr.Hash
+}
+
+type channel struct {
+	id           xdr.ID
+	contract     *contract
+	paused       bool
+	blocks       []xdr.Block
+	pending      []xdr.Transaction
+	transactions map[string]xdr.Transaction
+	receipts     map[string]xdr.Receipt
+}
+
+// New returns a node with no channels, channels are created on first use
+func New(opts ...Option) *Node {
+	n := &Node{
+		channels: make(map[string]*channel),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Script adds a scripted response to the node
+func (n *Node) Script(r *Response) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.responses = append(n.responses, r)
+}
+
+// channel returns the channel with the given hex id, creating it with a genesis block if needed
+func (n *Node) channel(channelId string) (*channel, error) {
+	if c, ok := n.channels[channelId]; ok {
+		return c, nil
+	}
+
+	id, err := xdr.IDFromHexString(channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &channel{
+		id:           id,
+		transactions: make(map[string]xdr.Transaction),
+		receipts:     make(map[string]xdr.Receipt),
+	}
+	c.blocks = append(c.blocks, xdr.Block{
+		Header: xdr.BlockHeader{Status: xdr.StatusFINALIZED},
+	})
+	n.channels[channelId] = c
+	return c, nil
+}
+
+// TransactionID returns the id of a transaction, the sha3 hash of its xdr encoding
+func TransactionID(tx *xdr.Transaction) (xdr.ID, error) {
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		return xdr.ID{}, err
+	}
+	hasher := &crypto.Sha3_256Hasher{}
+	return xdr.IDFromSlice(hasher.Hash(b))
+}
+
+// BlockID returns the hex id of a block, the sha3 hash of its xdr encoded header
+func BlockID(header *xdr.BlockHeader) (string, error) {
+	b, err := header.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	hasher := &crypto.Sha3_256Hasher{}
+	return crypto.ToHex(hasher.Hash(b)), nil
+}
+
+// Submit validates a transaction and queues it for the next block
+func (n *Node) Submit(tx *xdr.Transaction) (*xdr.ID, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c, err := n.channel(hex.EncodeToString(tx.Data.ChannelID[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := tx.Data.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(tx.Sender[:]), data, tx.Signature[:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	if n.checkExpiration && tx.Data.BlockExpirationNumber < c.height() {
+		return nil, ErrExpired
+	}
+
+	if r := n.response(c, tx); r != nil && r.Reject != "" {
+		return nil, errors.New(r.Reject)
+	}
+
+	id, err := TransactionID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.pending = append(c.pending, *tx)
+	if n.blockInterval == 0 {
+		n.produceBlock(c)
+	}
+	return &id, nil
+}
+
+// ProduceBlocks executes the pending transactions of every channel into a new block
+func (n *Node) ProduceBlocks() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, c := range n.channels {
+		if len(c.pending) > 0 {
+			n.produceBlock(c)
+		}
+	}
+}
+
+func (n *Node) produceBlock(c *channel) {
+	previous := c.blocks[len(c.blocks)-1].Header
+	previousHash, _ := previous.MarshalBinary()
+	hasher := &crypto.Sha3_256Hasher{}
+	previousId, _ := xdr.HashFromSlice(hasher.Hash(previousHash))
+
+	block := xdr.Block{
+		Header: xdr.BlockHeader{
+			BlockHeight:       previous.BlockHeight + 1,
+			TransactionHeight: previous.TransactionHeight + uint64(len(c.pending)),
+			PreviousHeader:    previousId,
+			Status:            xdr.StatusFINALIZED,
+		},
+		Transactions: c.pending,
+	}
+
+	for _, tx := range c.pending {
+		id, err := TransactionID(&tx)
+		if err != nil {
+			continue
+		}
+		key := hex.EncodeToString(id[:])
+		receipt := n.execute(c, &tx)
+		receipt.TransactionID = id
+		c.transactions[key] = tx
+		c.receipts[key] = receipt
+	}
+
+	c.pending = nil
+	c.blocks = append(c.blocks, block)
+}
+
+func failure(info string) xdr.Receipt {
+	return xdr.Receipt{
+		Status:     xdr.StatusFAILURE,
+		StatusInfo: xdr.StatusInfo(info),
+	}
+}
+
+// execute applies a transaction to the channel state and returns its receipt
+func (n *Node) execute(c *channel, tx *xdr.Transaction) xdr.Receipt {
+	category := tx.Data.Category
+	switch category.Type {
+	case xdr.CategoryTypeDEPLOY:
+		if category.Contract == nil {
+			return failure("missing contract")
+		}
+		if c.contract != nil && c.contract.owner != tx.Sender {
+			return failure("sender is not the contract owner")
+		}
+		c.contract = &contract{
+			owner:   category.Contract.Owner,
+			version: category.Contract.Version,
+			abi:     category.Contract.Abi,
+			hash:    category.Contract.ContractHash,
+		}
+		return xdr.Receipt{Status: xdr.StatusSUCCESS}
+	case xdr.CategoryTypePAUSE:
+		if c.contract == nil {
+			return failure("no contract deployed")
+		}
+		if c.contract.owner != tx.Sender {
+			return failure("sender is not the contract owner")
+		}
+		if category.Pause != nil {
+			c.paused = *category.Pause
+		}
+		return xdr.Receipt{Status: xdr.StatusSUCCESS}
+	case xdr.CategoryTypeDELETE:
+		if c.contract == nil {
+			return failure("no contract deployed")
+		}
+		if c.contract.owner != tx.Sender {
+			return failure("sender is not the contract owner")
+		}
+		c.contract = nil
+		c.paused = false
+		return xdr.Receipt{Status: xdr.StatusSUCCESS}
+	case xdr.CategoryTypeCALL:
+		if c.contract == nil {
+			return failure("no contract deployed")
+		}
+		if c.paused {
+			return failure("channel is paused")
+		}
+		if category.Call == nil {
+			return failure("missing call")
+		}
+		found := false
+		for _, f := range c.contract.abi.Functions {
+			found = found || f.FunctionName == category.Call.Function
+		}
+		if !found {
+			return failure("function " + category.Call.Function + " not found in abi")
+		}
+		if r := n.response(c, tx); r != nil {
+			status := xdr.Status(r.Status)
+			if status == xdr.StatusUNKNOWN {
+				status = xdr.StatusSUCCESS
+			}
+			return xdr.Receipt{
+				Status:     status,
+				Result:     r.Result,
+				StatusInfo: xdr.StatusInfo(r.StatusInfo),
+			}
+		}
+		return xdr.Receipt{Status: xdr.StatusSUCCESS}
+	default:
+		return failure("unknown transaction category")
+	}
+}
+
+// response returns the first scripted response matching a call transaction
+func (n *Node) response(c *channel, tx *xdr.Transaction) *Response {
+	call := tx.Data.Category.Call
+	if tx.Data.Category.Type != xdr.CategoryTypeCALL || call == nil {
+		return nil
+	}
+
+	for _, r := range n.responses {
+		if r.ChannelID != "" && r.ChannelID != hex.EncodeToString(c.id[:]) {
+			continue
+		}
+		if r.Function != call.Function {
+			continue
+		}
+		if r.Args != nil {
+			if len(r.Args) != len(call.Arguments) {
+				continue
+			}
+			match := true
+			for i, a := range r.Args {
+				match = match && a == string(call.Arguments[i])
+			}
+			if !match {
+				continue
+			}
+		}
+		return r
+	}
+	return nil
+}
+
+func (c *channel) height() uint64 {
+	return c.blocks[len(c.blocks)-1].Header.BlockHeight
+}
+
+// block returns a block by height or by block id
+func (c *channel) block(blockId string) (*xdr.Block, error) {
+	if height, err := strconv.ParseUint(blockId, 10, 64); err == nil && len(blockId) < 64 {
+		if height >= uint64(len(c.blocks)) {
+			return nil, ErrNotFound
+		}
+		return &c.blocks[height], nil
+	}
+	for i := range c.blocks {
+		id, err := BlockID(&c.blocks[i].Header)
+		if err != nil {
+			return nil, err
+		}
+		if id == blockId {
+			return &c.blocks[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// blockRange returns up to number blocks starting at height
+func (c *channel) blockRange(height int, number int) []xdr.Block {
+	blocks := make([]xdr.Block, 0, number)
+	for h := height; h >= 0 && h < len(c.blocks) && len(blocks) < number; h++ {
+		blocks = append(blocks, c.blocks[h])
+	}
+	return blocks
+}
+
+func (n *Node) lookup(channelId string, f func(c *channel) (xdr.Response, error)) (xdr.Response, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c, err := n.channel(channelId)
+	if err != nil {
+		return xdr.Response{}, err
+	}
+	return f(c)
+}
+
+func notFound(entity string, id string) error {
+	return fmt.Errorf("%s %s %w", entity, id, ErrNotFound)
+}
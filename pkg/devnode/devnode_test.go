@@ -0,0 +1,208 @@
+package devnode
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-go"
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+const testChannel = "2222222222222222222222222222222222222222222222222222222222222222"
+
+var testAbi = &xdr.Abi{
+	Version: "1",
+	Functions: []xdr.FunctionSignature{
+		{FunctionType: xdr.FunctionTypeWRITE, FunctionName: "foo"},
+	},
+}
+
+type testNode struct {
+	client  mazzaroth.Client
+	node    *Node
+	owner   xdr.ID
+	privKey ed25519.PrivateKey
+	nonce   uint64
+}
+
+func newTestNode(t *testing.T, opts ...Option) *testNode {
+	node := New(opts...)
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	client, err := mazzaroth.NewMazzarothClient(mazzaroth.WithAddress(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := xdr.IDFromSlice(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testNode{client: client, node: node, owner: owner, privKey: priv}
+}
+
+func (n *testNode) channelID(t *testing.T) xdr.ID {
+	id, err := xdr.IDFromHexString(testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// submit signs and submits a deploy transaction, or a call of the function when it is set
+func (n *testNode) submit(t *testing.T, function string, args ...xdr.Argument) (string, error) {
+	n.nonce++
+	builder := mazzaroth.Transaction(n.owner, n.channelID(t))
+	var tx *xdr.Transaction
+	var err error
+	if function == "" {
+		tx, err = builder.Contract(n.nonce, 100).Deploy(n.owner, "0.0.1", testAbi, []byte("contract")).Sign(n.privKey)
+	} else {
+		tx, err = builder.Call(n.nonce, 100).Function(function).Arguments(args...).Sign(n.privKey)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _, err := n.client.TransactionSubmit(context.Background(), tx)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+func (n *testNode) height(t *testing.T) uint64 {
+	height, err := n.client.BlockHeight(context.Background(), testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return height.Height
+}
+
+func TestSubmit(t *testing.T) {
+	script := &Script{Responses: []*Response{
+		{Function: "foo", Args: []string{"1"}, Result: "one"},
+		{Function: "foo", Args: []string{"2"}, Status: int32(xdr.StatusFAILURE), StatusInfo: "two failed"},
+		{Function: "foo", Args: []string{"3"}, Reject: "three rejected"},
+	}}
+
+	tests := []struct {
+		name       string
+		deploy     bool
+		args       []xdr.Argument
+		rejected   bool
+		status     xdr.Status
+		result     string
+		statusInfo string
+	}{
+		{name: "call without contract", args: []xdr.Argument{"1"}, status: xdr.StatusFAILURE, statusInfo: "no contract deployed"},
+		{name: "scripted result", deploy: true, args: []xdr.Argument{"1"}, status: xdr.StatusSUCCESS, result: "one"},
+		{name: "scripted failure", deploy: true, args: []xdr.Argument{"2"}, status: xdr.StatusFAILURE, statusInfo: "two failed"},
+		{name: "unscripted call", deploy: true, args: []xdr.Argument{"4"}, status: xdr.StatusSUCCESS},
+		{name: "rejected submit", deploy: true, args: []xdr.Argument{"3"}, rejected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := newTestNode(t, WithScript(script))
+			if test.deploy {
+				if _, err := n.submit(t, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			id, err := n.submit(t, "foo", test.args...)
+			if test.rejected {
+				if err == nil {
+					t.Fatal("expected submit to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			receipt, err := n.client.ReceiptLookup(context.Background(), testChannel, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Status != test.status {
+				t.Errorf("expected status %d, got %d", test.status, receipt.Status)
+			}
+			if receipt.Result != test.result {
+				t.Errorf("expected result %q, got %q", test.result, receipt.Result)
+			}
+			if string(receipt.StatusInfo) != test.statusInfo {
+				t.Errorf("expected status info %q, got %q", test.statusInfo, receipt.StatusInfo)
+			}
+
+			tx, err := n.client.TransactionLookup(context.Background(), testChannel, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if call, ok := tx.Data.Category.GetCall(); !ok || call.Function != "foo" {
+				t.Errorf("expected a call of foo, got %+v", tx.Data.Category)
+			}
+		})
+	}
+}
+
+func TestBlockAdvance(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		submits  int
+		produce  bool
+		expected uint64
+	}{
+		{name: "block per transaction", submits: 3, expected: 3},
+		{name: "pending until produced", opts: []Option{WithBlockInterval(time.Hour)}, submits: 3, expected: 0},
+		{name: "batched into one block", opts: []Option{WithBlockInterval(time.Hour)}, submits: 3, produce: true, expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := newTestNode(t, test.opts...)
+			ids := make([]string, 0, test.submits)
+			for i := 0; i < test.submits; i++ {
+				id, err := n.submit(t, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			if test.produce {
+				n.node.ProduceBlocks()
+			}
+
+			if height := n.height(t); height != test.expected {
+				t.Fatalf("expected height %d, got %d", test.expected, height)
+			}
+			blocks, err := n.client.BlockList(context.Background(), testChannel, int(test.expected), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(blocks) != 1 || blocks[0].Header.BlockHeight != test.expected {
+				t.Fatalf("expected block %d, got %+v", test.expected, blocks)
+			}
+
+			for _, id := range ids {
+				_, err := n.client.ReceiptLookup(context.Background(), testChannel, id)
+				if test.expected == 0 && err == nil {
+					t.Errorf("expected no receipt for pending transaction %s", id)
+				}
+				if test.expected > 0 && err != nil {
+					t.Errorf("expected receipt for transaction %s: %v", id, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package devnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kochavalabs/mazzaroth-xdr/go-xdr/xdr"
+)
+
+var _ http.Handler = &Node{}
+
+// ServeHTTP serves the /v1/channels endpoints of the gateway api
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "v1" || parts[1] != "channels" {
+		http.NotFound(w, r)
+		return
+	}
+	channelId := parts[2]
+	resource := parts[3:]
+
+	var resp xdr.Response
+	var err error
+	switch {
+	case r.Method == http.MethodPost && len(resource) == 1 && resource[0] == "transactions":
+		resp, err = n.submit(r)
+	case r.Method != http.MethodGet:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	case len(resource) == 2 && resource[0] == "blocks" && resource[1] == "height":
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			return xdr.NewResponse(xdr.ResponseTypeHEIGHT, xdr.BlockHeight{Height: c.height()})
+		})
+	case len(resource) == 1 && (resource[0] == "blocks" || resource[0] == "blockheaders"):
+		height, number, qerr := rangeQuery(r)
+		if qerr != nil {
+			http.Error(w, qerr.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			blocks := c.blockRange(height, number)
+			if resource[0] == "blocks" {
+				return xdr.NewResponse(xdr.ResponseTypeBLOCKLIST, blocks)
+			}
+			headers := make([]xdr.BlockHeader, 0, len(blocks))
+			for _, b := range blocks {
+				headers = append(headers, b.Header)
+			}
+			return xdr.NewResponse(xdr.ResponseTypeBLOCKHEADERLIST, headers)
+		})
+	case len(resource) == 2 && (resource[0] == "blocks" || resource[0] == "blockheaders"):
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			block, err := c.block(resource[1])
+			if err != nil {
+				return xdr.Response{}, notFound("block", resource[1])
+			}
+			if resource[0] == "blocks" {
+				return xdr.NewResponse(xdr.ResponseTypeBLOCK, *block)
+			}
+			return xdr.NewResponse(xdr.ResponseTypeBLOCKHEADER, block.Header)
+		})
+	case len(resource) == 1 && resource[0] == "abi":
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			if c.contract == nil {
+				return xdr.Response{}, notFound("abi for channel", channelId)
+			}
+			return xdr.NewResponse(xdr.ResponseTypeABI, c.contract.abi)
+		})
+	case len(resource) == 2 && resource[0] == "receipts":
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			receipt, ok := c.receipts[resource[1]]
+			if !ok {
+				return xdr.Response{}, notFound("receipt", resource[1])
+			}
+			return xdr.NewResponse(xdr.ResponseTypeRECEIPT, receipt)
+		})
+	case len(resource) == 2 && resource[0] == "transactions":
+		resp, err = n.lookup(channelId, func(c *channel) (xdr.Response, error) {
+			tx, ok := c.transactions[resource[1]]
+			if !ok {
+				return xdr.Response{}, notFound("transaction", resource[1])
+			}
+			return xdr.NewResponse(xdr.ResponseTypeTRANSACTION, tx)
+		})
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (n *Node) submit(r *http.Request) (xdr.Response, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return xdr.Response{}, err
+	}
+	tx := &xdr.Transaction{}
+	if err := json.Unmarshal(body, tx); err != nil {
+		return xdr.Response{}, err
+	}
+	id, err := n.Submit(tx)
+	if err != nil {
+		return xdr.Response{}, err
+	}
+	return xdr.NewResponse(xdr.ResponseTypeTRANSACTIONID, *id)
+}
+
+func rangeQuery(r *http.Request) (int, int, error) {
+	height, err := strconv.Atoi(r.URL.Query().Get("height"))
+	if err != nil {
+		return 0, 0, errors.New("invalid height")
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("number"))
+	if err != nil {
+		return 0, 0, errors.New("invalid number")
+	}
+	return height, number, nil
+}
+
+// ListenAndServe serves the node on addr until the context is cancelled
+func (n *Node) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: n,
+	}
+
+	if n.blockInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(n.blockInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					n.ProduceBlocks()
+				}
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}